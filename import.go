@@ -0,0 +1,111 @@
+package main
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/importer"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+const importUsage = `usage: import [-format csv|ndjson] [-create-missing] [-batch n] <file | ->
+
+  Creates a product for each valid row of a CSV or NDJSON feed, naming
+  categories and brands instead of giving IDs, and lists the rows that
+  failed. "-" reads the feed from stdin. The format defaults to the file
+  extension (.csv, .ndjson or .jsonl).
+
+  -format          csv or ndjson
+  -create-missing  create categories and brands that no record matches
+  -batch n         rows per transaction (default 100)`
+
+// runImport implements the "import" subcommand against the configured database.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", "", "")
+	createMissing := flags.Bool("create-missing", false, "")
+	batch := flags.Int("batch", importer.DefaultBatchSize, "")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *batch < 1 {
+		return errors.New(importUsage)
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = string(importer.CSV)
+		case ".ndjson", ".jsonl":
+			*format = string(importer.NDJSON)
+		default:
+			return fmt.Errorf("cannot tell the format of %q, pass -format", path)
+		}
+	}
+	if *format != string(importer.CSV) && *format != string(importer.NDJSON) {
+		return errors.New(importUsage)
+	}
+
+	var input io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	imp := importer.New(
+		repository.NewGormProductRepository(config.DB),
+		repository.NewGormCategoryRepository(config.DB),
+		repository.NewGormBrandRepository(config.DB),
+	)
+	report, err := imp.Import(context.Background(), bufio.NewReader(input), importer.Options{
+		Format:        importer.Format(*format),
+		CreateMissing: *createMissing,
+		BatchSize:     *batch,
+	})
+	if report != nil {
+		if perr := printImportReport(report); perr != nil {
+			return perr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", report.Failed, report.Rows)
+	}
+	return nil
+}
+
+func printImportReport(report *models.ImportReport) error {
+	fmt.Printf("Read %d row(s): %d created, %d failed\n", report.Rows, report.Created, report.Failed)
+	if len(report.CreatedCategories) > 0 {
+		fmt.Printf("Created categories: %s\n", strings.Join(report.CreatedCategories, ", "))
+	}
+	if len(report.CreatedBrands) > 0 {
+		fmt.Printf("Created brands: %s\n", strings.Join(report.CreatedBrands, ", "))
+	}
+	if len(report.Errors) == 0 {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nLINE\tFIELD\tERROR")
+	for _, rowErr := range report.Errors {
+		for _, field := range rowErr.Errors {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", rowErr.Row, field.Field, field.Message)
+		}
+	}
+	return w.Flush()
+}
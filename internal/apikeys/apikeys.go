@@ -0,0 +1,62 @@
+// Package apikeys generates and hashes partner API keys.
+//
+// A key looks like "sk_<prefix>_<secret>". The prefix is stored in clear so a
+// key can be looked up by it; the full key is only ever stored as a SHA-256
+// hash. Keys carry 256 bits of randomness, so a fast hash is sufficient.
+package apikeys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const keyPrefix = "sk_"
+
+// idLength is the number of random bytes in a lookup prefix. Prefixes are
+// unique in storage, so they must be long enough never to collide.
+const idLength = 8
+
+// Generate returns a new plaintext key, its lookup prefix and its hash.
+func Generate() (key, prefix, hash string, err error) {
+	id := make([]byte, idLength)
+	secret := make([]byte, 32)
+	if _, err = rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = keyPrefix + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, Hash(key), nil
+}
+
+// Prefix returns the lookup prefix of a presented key, or false if the key is malformed.
+func Prefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return "", false
+	}
+	// The secret is base64url and may itself contain '_', so split at the
+	// first separator after "sk_", not the last one.
+	i := strings.IndexByte(key[len(keyPrefix):], '_') + len(keyPrefix)
+	if i <= len(keyPrefix) || i == len(key)-1 {
+		return "", false
+	}
+	return key[:i], true
+}
+
+// Hash returns the hex SHA-256 of key.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify reports whether key matches the stored hash, in constant time.
+func Verify(key, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(hash)) == 1
+}
@@ -0,0 +1,61 @@
+package apikeys
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratePrefixRoundTrip(t *testing.T) {
+	sawUnderscore := false
+	for i := 0; i < 1000; i++ {
+		key, prefix, hash, err := Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(key[len(prefix)+1:], "_") {
+			sawUnderscore = true
+		}
+		got, ok := Prefix(key)
+		if !ok || got != prefix {
+			t.Fatalf("Prefix(%q) = %q, %v; want %q", key, got, ok, prefix)
+		}
+		if !Verify(key, hash) {
+			t.Fatalf("Verify(%q) failed", key)
+		}
+	}
+	if !sawUnderscore {
+		t.Fatal("no generated secret contained '_'")
+	}
+}
+
+func TestPrefixSecretWithUnderscore(t *testing.T) {
+	got, ok := Prefix("sk_0123abcd_ab_cd_ef")
+	if !ok || got != "sk_0123abcd" {
+		t.Fatalf("Prefix = %q, %v; want sk_0123abcd", got, ok)
+	}
+}
+
+func TestPrefixMalformed(t *testing.T) {
+	for _, key := range []string{"", "sk_", "sk__x", "sk_abc", "sk_abc_", "pk_abc_def"} {
+		if p, ok := Prefix(key); ok {
+			t.Errorf("Prefix(%q) = %q, true; want false", key, p)
+		}
+	}
+}
+
+func TestGenerateUniquePrefixes(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		_, prefix, _, err := Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(prefix) != len(keyPrefix)+2*idLength {
+			t.Fatalf("prefix %q has %d characters, want %d", prefix, len(prefix), len(keyPrefix)+2*idLength)
+		}
+		if seen[prefix] {
+			t.Fatalf("prefix %q was generated twice", prefix)
+		}
+		seen[prefix] = true
+	}
+}
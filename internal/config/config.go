@@ -1,9 +1,16 @@
 package config
 
 import (
-	"log"
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/viper"
 )
 
@@ -19,10 +26,110 @@ type App struct {
 	EnableHelmet    bool
 	EnableLimiter   bool
 
+	// RateLimitTiers maps tier names used by routes to their limits. The
+	// "default" tier comes from RATE_LIMIT_MAX / RATE_LIMIT_WINDOW.
+	RateLimitTiers map[string]RateLimitTier
+	RateLimitStore string // memory or sql
+
+	// TrustedProxies are the proxy IPs/CIDRs whose X-Forwarded-For is believed.
+	TrustedProxies []string
+
 	DBDriver string
 	DBDSN    string
 
+	// MigrateOnStart applies pending schema migrations when the server boots.
+	MigrateOnStart bool
+
 	LogToFile bool
+
+	// LogFile is rotated when it reaches LogMaxSize bytes or crosses a
+	// LogRotateInterval boundary; rotated files are gzipped when LogCompress
+	// is set and deleted past LogMaxAge or beyond the newest LogMaxFiles.
+	LogFile           string
+	LogFileMode       os.FileMode
+	LogMaxSize        int64
+	LogRotateInterval time.Duration
+	LogMaxAge         time.Duration
+	LogMaxFiles       int
+	LogCompress       bool
+
+	// LogLevel (debug, info, warn, error) and LogFormat (json or text) shape
+	// the structured log output.
+	LogLevel  string
+	LogFormat string
+
+	// ShutdownDelay keeps serving after readiness flips so load balancers can
+	// notice; ShutdownTimeout bounds how long in-flight requests may drain.
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	// Tracing selects the OpenTelemetry span exporter: none, stdout or
+	// otlp-file (OTLP/JSON lines appended to TracingFile).
+	ServiceName        string
+	TracingExporter    string
+	TracingFile        string
+	TracingSampleRatio float64
+
+	// MetricsEnabled serves Prometheus metrics on /metrics.
+	MetricsEnabled bool
+
+	// I18nDir holds extra or overriding translation catalogs
+	// (universal-translator JSON); empty uses the built-in ones only.
+	I18nDir string
+
+	// HealthCheckTimeout bounds each dependency check behind /readyz.
+	HealthCheckTimeout time.Duration
+
+	// CursorSecret signs pagination cursors so clients cannot forge them.
+	CursorSecret []byte
+
+	// CacheControl is the Cache-Control header of successful GETs per route
+	// group ("products", "categories", "brands"); empty sends none.
+	CacheControl map[string]string
+
+	// IdempotencyTTL is how long responses to POSTs with an Idempotency-Key
+	// are replayed; IdempotencyLockTimeout how long a key stays claimed by a
+	// request that never finishes (e.g. the process died).
+	IdempotencyTTL         time.Duration
+	IdempotencyLockTimeout time.Duration
+
+	// BodyLimit caps request bodies, which are read into memory; ImportMaxSize
+	// caps the product feeds of POST /products/import, which are streamed
+	// (0 = no limit).
+	BodyLimit     int64
+	ImportMaxSize int64
+
+	// RequireIfMatch rejects PUT, PATCH and DELETE on catalog resources
+	// without an If-Match header (428) instead of treating it as optional.
+	RequireIfMatch bool
+
+	// AuthEnabled guards mutating routes with JWT bearer authentication.
+	AuthEnabled  bool
+	JWTAlgorithm string      // HS256, RS256 or EdDSA
+	JWTKey       interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey depending on JWTAlgorithm
+	JWTIssuer    string
+	JWTAudience  string
+
+	// RolePermissions maps each role to the permissions it grants
+	// (e.g. "products:write", "brands:*" or "*").
+	RolePermissions map[string][]string
+}
+
+// RateLimitTier allows Max requests per Window for each caller.
+type RateLimitTier struct {
+	Max    int
+	Window time.Duration
+}
+
+// DefaultRolePermissions is the role policy used when RBAC_POLICY_FILE is not set.
+var DefaultRolePermissions = map[string][]string{
+	"viewer": {"products:read", "brands:read", "categories:read"},
+	"editor": {
+		"products:read", "products:write",
+		"brands:read", "brands:write",
+		"categories:read", "categories:write",
+	},
+	"catalog-admin": {"products:*", "brands:*", "categories:*", "apikeys:*"},
 }
 
 // Load reads .env / environment and returns a populated App struct.
@@ -30,9 +137,9 @@ func Load() (*App, error) {
 	viper.SetConfigFile(".env")
 
 	if err := viper.ReadInConfig(); err != nil {
-		log.Println("⚠️  .env file not found or unreadable. Falling back to OS environment...", err)
+		slog.Warn(".env file not found or unreadable. Falling back to OS environment", "error", err)
 	} else {
-		log.Printf("✅ Loaded config from: %s\n", viper.ConfigFileUsed())
+		slog.Info("Loaded config", "file", viper.ConfigFileUsed())
 	}
 
 	viper.AutomaticEnv()
@@ -45,43 +152,324 @@ func Load() (*App, error) {
 	viper.SetDefault("RATE_LIMIT_WINDOW", "1m")
 	viper.SetDefault("ENABLE_HELMET", true)
 	viper.SetDefault("ENABLE_RATE_LIMITER", true)
+	viper.SetDefault("RATE_LIMIT_TIERS", "write=30/1m,admin=10/1m,export=5/1m")
+	viper.SetDefault("RATE_LIMIT_STORE", "memory")
 	viper.SetDefault("DB_DRIVER", "sqlite")
 	viper.SetDefault("DB_DSN", "catalog.db")
+	viper.SetDefault("MIGRATE_ON_START", true)
 	viper.SetDefault("LOG_TO_FILE", true)
+	viper.SetDefault("LOG_LEVEL", "info")
+	viper.SetDefault("LOG_FORMAT", "json")
+	viper.SetDefault("LOG_FILE", "logs/server.log")
+	viper.SetDefault("LOG_FILE_MODE", "0640")
+	viper.SetDefault("LOG_MAX_SIZE", "100MB")
+	viper.SetDefault("LOG_ROTATE_INTERVAL", "24h")
+	viper.SetDefault("LOG_MAX_AGE", "720h")
+	viper.SetDefault("LOG_MAX_FILES", 10)
+	viper.SetDefault("LOG_COMPRESS", true)
+	viper.SetDefault("SHUTDOWN_DELAY", "0s")
+	viper.SetDefault("SHUTDOWN_TIMEOUT", "15s")
+	viper.SetDefault("HEALTH_CHECK_TIMEOUT", "2s")
+	viper.SetDefault("METRICS_ENABLED", true)
+	viper.SetDefault("OTEL_SERVICE_NAME", "catalog-api")
+	viper.SetDefault("TRACING_EXPORTER", "none")
+	viper.SetDefault("TRACING_FILE", "logs/traces.jsonl")
+	viper.SetDefault("TRACING_SAMPLE_RATIO", 1.0)
+	viper.SetDefault("CACHE_CONTROL_PRODUCTS", "no-cache")
+	viper.SetDefault("CACHE_CONTROL_CATEGORIES", "no-cache")
+	viper.SetDefault("CACHE_CONTROL_BRANDS", "no-cache")
+	viper.SetDefault("IDEMPOTENCY_TTL", "24h")
+	viper.SetDefault("IDEMPOTENCY_LOCK_TIMEOUT", "1m")
+	viper.SetDefault("BODY_LIMIT", "4MB")
+	viper.SetDefault("IMPORT_MAX_SIZE", "1GB")
+	viper.SetDefault("AUTH_ENABLED", true)
+	viper.SetDefault("JWT_ALGORITHM", "HS256")
 
 	// Parse duration safely
 	windowStr := viper.GetString("RATE_LIMIT_WINDOW")
 	window, err := time.ParseDuration(windowStr)
 	if err != nil {
-		log.Printf("❌ Failed to parse RATE_LIMIT_WINDOW '%s': %v\n", windowStr, err)
-		return nil, err
+		return nil, fmt.Errorf("RATE_LIMIT_WINDOW %q: %w", windowStr, err)
+	}
+
+	// Graceful shutdown timings
+	shutdownDelay, err := time.ParseDuration(viper.GetString("SHUTDOWN_DELAY"))
+	if err != nil {
+		return nil, fmt.Errorf("SHUTDOWN_DELAY: %w", err)
+	}
+	shutdownTimeout, err := time.ParseDuration(viper.GetString("SHUTDOWN_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("SHUTDOWN_TIMEOUT: %w", err)
+	}
+
+	healthCheckTimeout, err := time.ParseDuration(viper.GetString("HEALTH_CHECK_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("HEALTH_CHECK_TIMEOUT: %w", err)
+	}
+
+	// Per-route rate limit tiers
+	tiers, err := parseRateLimitTiers(viper.GetString("RATE_LIMIT_TIERS"))
+	if err != nil {
+		return nil, fmt.Errorf("RATE_LIMIT_TIERS: %w", err)
 	}
+	tiers["default"] = RateLimitTier{Max: viper.GetInt("RATE_LIMIT_MAX"), Window: window}
 
-	// Debug log: Print loaded values
-	log.Println("    Loaded Configuration:")
-	log.Printf("   APP_PORT: %d\n", viper.GetInt("APP_PORT"))
-	log.Printf("   ENVIRONMENT: %s\n", viper.GetString("ENVIRONMENT"))
-	log.Printf("   DB_DRIVER: %s\n", viper.GetString("DB_DRIVER"))
-	log.Printf("   DB_DSN: %s\n", viper.GetString("DB_DSN"))
-	log.Printf("   LOG_TO_FILE: %v\n", viper.GetBool("LOG_TO_FILE"))
-	log.Printf("   FRONTEND_ORIGINS: %v\n", viper.GetStringSlice("FRONTEND_ORIGINS"))
-	log.Printf("   RATE_LIMIT_MAX: %d\n", viper.GetInt("RATE_LIMIT_MAX"))
-	log.Printf("   RATE_LIMIT_WINDOW: %s\n", window)
-	log.Printf("   ENABLE_HELMET: %v\n", viper.GetBool("ENABLE_HELMET"))
-	log.Printf("   ENABLE_RATE_LIMITER: %v\n", viper.GetBool("ENABLE_RATE_LIMITER"))
+	var trustedProxies []string
+	for _, proxy := range strings.Split(viper.GetString("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
+	rateLimitStore := viper.GetString("RATE_LIMIT_STORE")
+	if rateLimitStore != "memory" && rateLimitStore != "sql" {
+		return nil, fmt.Errorf("unsupported RATE_LIMIT_STORE %q (use memory or sql)", rateLimitStore)
+	}
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(viper.GetString("LOG_LEVEL"))); err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	logFormat := strings.ToLower(viper.GetString("LOG_FORMAT"))
+	if logFormat != "json" && logFormat != "text" {
+		return nil, fmt.Errorf("unsupported LOG_FORMAT %q (use json or text)", logFormat)
+	}
+
+	// Log file rotation
+	logFileMode, err := strconv.ParseUint(viper.GetString("LOG_FILE_MODE"), 8, 32)
+	if err != nil || logFileMode > 0o777 {
+		return nil, fmt.Errorf("invalid LOG_FILE_MODE %q (use octal, e.g. 0640)", viper.GetString("LOG_FILE_MODE"))
+	}
+	logMaxSize, err := parseByteSize(viper.GetString("LOG_MAX_SIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("LOG_MAX_SIZE: %w", err)
+	}
+	logRotateInterval, err := time.ParseDuration(viper.GetString("LOG_ROTATE_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("LOG_ROTATE_INTERVAL: %w", err)
+	}
+	logMaxAge, err := time.ParseDuration(viper.GetString("LOG_MAX_AGE"))
+	if err != nil {
+		return nil, fmt.Errorf("LOG_MAX_AGE: %w", err)
+	}
+
+	// Cursor signing key; a random one only works for this process
+	cursorSecret := []byte(viper.GetString("CURSOR_SECRET"))
+	if len(cursorSecret) == 0 {
+		slog.Warn("CURSOR_SECRET not set. Using a random key; cursors will not survive restarts or work across replicas")
+		cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cursorSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	// Idempotency keys
+	idempotencyTTL, err := time.ParseDuration(viper.GetString("IDEMPOTENCY_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL: %w", err)
+	}
+	idempotencyLockTimeout, err := time.ParseDuration(viper.GetString("IDEMPOTENCY_LOCK_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("IDEMPOTENCY_LOCK_TIMEOUT: %w", err)
+	}
+	if idempotencyTTL <= 0 || idempotencyLockTimeout <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL and IDEMPOTENCY_LOCK_TIMEOUT must be positive")
+	}
+
+	// Request body limits
+	bodyLimit, err := parseByteSize(viper.GetString("BODY_LIMIT"))
+	if err != nil {
+		return nil, fmt.Errorf("BODY_LIMIT: %w", err)
+	}
+	if bodyLimit == 0 || bodyLimit > math.MaxInt32 {
+		return nil, fmt.Errorf("BODY_LIMIT must be between 1 byte and 2GB")
+	}
+	importMaxSize, err := parseByteSize(viper.GetString("IMPORT_MAX_SIZE"))
+	if err != nil {
+		return nil, fmt.Errorf("IMPORT_MAX_SIZE: %w", err)
+	}
+
+	// JWT verification key
+	authEnabled := viper.GetBool("AUTH_ENABLED")
+	jwtAlgorithm := viper.GetString("JWT_ALGORITHM")
+	var jwtKey interface{}
+	if authEnabled {
+		jwtKey, err = loadJWTKey(jwtAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key for %s: %w", jwtAlgorithm, err)
+		}
+	}
+
+	// Role → permission policy
+	rolePermissions := DefaultRolePermissions
+	if path := viper.GetString("RBAC_POLICY_FILE"); path != "" {
+		rolePermissions, err = loadRolePermissions(path)
+		if err != nil {
+			return nil, fmt.Errorf("RBAC_POLICY_FILE %q: %w", path, err)
+		}
+	}
 
 	// Return the populated config
 	return &App{
-		Port:            viper.GetInt("APP_PORT"),
-		Environment:     viper.GetString("ENVIRONMENT"),
-		FrontendOrigins: viper.GetStringSlice("FRONTEND_ORIGINS"),
-		CORSAllowCreds:  viper.GetBool("CORS_ALLOW_CREDENTIALS"),
-		RateLimitMax:    viper.GetInt("RATE_LIMIT_MAX"),
-		RateLimitWindow: window,
-		EnableHelmet:    viper.GetBool("ENABLE_HELMET"),
-		EnableLimiter:   viper.GetBool("ENABLE_RATE_LIMITER"),
-		DBDriver:        viper.GetString("DB_DRIVER"),
-		DBDSN:           viper.GetString("DB_DSN"),
-		LogToFile:       viper.GetBool("LOG_TO_FILE"),
+		Port:               viper.GetInt("APP_PORT"),
+		Environment:        viper.GetString("ENVIRONMENT"),
+		FrontendOrigins:    viper.GetStringSlice("FRONTEND_ORIGINS"),
+		CORSAllowCreds:     viper.GetBool("CORS_ALLOW_CREDENTIALS"),
+		RateLimitMax:       viper.GetInt("RATE_LIMIT_MAX"),
+		RateLimitWindow:    window,
+		EnableHelmet:       viper.GetBool("ENABLE_HELMET"),
+		EnableLimiter:      viper.GetBool("ENABLE_RATE_LIMITER"),
+		RateLimitTiers:     tiers,
+		RateLimitStore:     rateLimitStore,
+		TrustedProxies:     trustedProxies,
+		DBDriver:           viper.GetString("DB_DRIVER"),
+		DBDSN:              viper.GetString("DB_DSN"),
+		MigrateOnStart:     viper.GetBool("MIGRATE_ON_START"),
+		LogToFile:          viper.GetBool("LOG_TO_FILE"),
+		LogLevel:           viper.GetString("LOG_LEVEL"),
+		LogFormat:          logFormat,
+		LogFile:            viper.GetString("LOG_FILE"),
+		LogFileMode:        os.FileMode(logFileMode),
+		LogMaxSize:         logMaxSize,
+		LogRotateInterval:  logRotateInterval,
+		LogMaxAge:          logMaxAge,
+		LogMaxFiles:        viper.GetInt("LOG_MAX_FILES"),
+		LogCompress:        viper.GetBool("LOG_COMPRESS"),
+		ShutdownDelay:      shutdownDelay,
+		ShutdownTimeout:    shutdownTimeout,
+		HealthCheckTimeout: healthCheckTimeout,
+		I18nDir:            viper.GetString("I18N_DIR"),
+		MetricsEnabled:     viper.GetBool("METRICS_ENABLED"),
+		ServiceName:        viper.GetString("OTEL_SERVICE_NAME"),
+		TracingExporter:    viper.GetString("TRACING_EXPORTER"),
+		TracingFile:        viper.GetString("TRACING_FILE"),
+		TracingSampleRatio: viper.GetFloat64("TRACING_SAMPLE_RATIO"),
+		CursorSecret:       cursorSecret,
+		CacheControl: map[string]string{
+			"products":   viper.GetString("CACHE_CONTROL_PRODUCTS"),
+			"categories": viper.GetString("CACHE_CONTROL_CATEGORIES"),
+			"brands":     viper.GetString("CACHE_CONTROL_BRANDS"),
+		},
+		IdempotencyTTL:         idempotencyTTL,
+		IdempotencyLockTimeout: idempotencyLockTimeout,
+		BodyLimit:              bodyLimit,
+		ImportMaxSize:          importMaxSize,
+		RequireIfMatch:         viper.GetBool("REQUIRE_IF_MATCH"),
+		AuthEnabled:            authEnabled,
+		JWTAlgorithm:           jwtAlgorithm,
+		JWTKey:                 jwtKey,
+		JWTIssuer:              viper.GetString("JWT_ISSUER"),
+		JWTAudience:            viper.GetString("JWT_AUDIENCE"),
+		RolePermissions:        rolePermissions,
 	}, nil
 }
+
+// parseRateLimitTiers parses a comma-separated list of "name=max/window"
+// entries, e.g. "write=30/1m,admin=10/1m".
+func parseRateLimitTiers(raw string) (map[string]RateLimitTier, error) {
+	tiers := make(map[string]RateLimitTier)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, spec, ok := strings.Cut(entry, "=")
+		rawMax, rawWindow, ok2 := strings.Cut(spec, "/")
+		if !ok || !ok2 || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid tier %q (want name=max/window)", entry)
+		}
+
+		max, err := strconv.Atoi(rawMax)
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("invalid max in tier %q", entry)
+		}
+		window, err := time.ParseDuration(rawWindow)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("invalid window in tier %q", entry)
+		}
+
+		tiers[strings.TrimSpace(name)] = RateLimitTier{Max: max, Window: window}
+	}
+	return tiers, nil
+}
+
+// parseByteSize parses sizes such as "100MB", "512KB", "1GB" or a plain byte
+// count. Units are binary (1KB = 1024 bytes); "0" disables the limit.
+func parseByteSize(raw string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (e.g. 100MB)", raw)
+	}
+	return n * multiplier, nil
+}
+
+// loadRolePermissions reads a role policy file (YAML, JSON or TOML) of the form
+//
+//	roles:
+//	  editor: [products:write, brands:write]
+func loadRolePermissions(path string) (map[string][]string, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	roles := v.GetStringMapStringSlice("roles")
+	if len(roles) == 0 {
+		return nil, fmt.Errorf("no roles defined")
+	}
+	return roles, nil
+}
+
+// devJWTSecret is the HS256 secret committed in .env for local development.
+// Being public, it is refused outside the development environment.
+const devJWTSecret = "dev-only-secret-change-me-0123456789"
+
+// loadJWTKey returns the verification key for algorithm. HS256 reads the shared
+// JWT_SECRET; RS256 and EdDSA read a PEM public key from JWT_PUBLIC_KEY or the
+// file named by JWT_PUBLIC_KEY_FILE.
+func loadJWTKey(algorithm string) (interface{}, error) {
+	if algorithm == "HS256" {
+		secret := viper.GetString("JWT_SECRET")
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("JWT_SECRET must be at least 32 characters")
+		}
+		if secret == devJWTSecret && viper.GetString("ENVIRONMENT") != "development" {
+			return nil, fmt.Errorf("JWT_SECRET is the public development secret from .env; set a random one outside development")
+		}
+		return []byte(secret), nil
+	}
+
+	pemData := []byte(viper.GetString("JWT_PUBLIC_KEY"))
+	if path := viper.GetString("JWT_PUBLIC_KEY_FILE"); len(pemData) == 0 && path != "" {
+		var err error
+		if pemData, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if len(pemData) == 0 {
+		return nil, fmt.Errorf("JWT_PUBLIC_KEY or JWT_PUBLIC_KEY_FILE is required")
+	}
+
+	switch algorithm {
+	case "RS256":
+		return jwt.ParseRSAPublicKeyFromPEM(pemData)
+	case "EdDSA":
+		return jwt.ParseEdPublicKeyFromPEM(pemData)
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q (use HS256, RS256 or EdDSA)", algorithm)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoadJWTKeyHS256(t *testing.T) {
+	t.Cleanup(viper.Reset)
+	tests := []struct {
+		secret, environment string
+		wantErr             string
+	}{
+		{"too-short", "production", "at least 32 characters"},
+		{devJWTSecret, "production", "public development secret"},
+		{devJWTSecret, "", "public development secret"},
+		{devJWTSecret, "development", ""},
+		{"a-random-secret-of-forty-characters-0000", "production", ""},
+	}
+	for _, tt := range tests {
+		viper.Set("JWT_SECRET", tt.secret)
+		viper.Set("ENVIRONMENT", tt.environment)
+		key, err := loadJWTKey("HS256")
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%q in %q: %v", tt.secret, tt.environment, err)
+		case tt.wantErr == "" && string(key.([]byte)) != tt.secret:
+			t.Errorf("%q in %q: key = %q", tt.secret, tt.environment, key)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%q in %q: err = %v, want %q", tt.secret, tt.environment, err, tt.wantErr)
+		}
+	}
+}
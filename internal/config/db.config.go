@@ -1,12 +1,15 @@
 package config
 
 import (
-	"gorm.io/gorm/logger"
-	"log"
-	"os"
+	"context"
+	"errors"
+	"log/slog"
 	"time"
 
-	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/logging"
+	"Scalable-Secure-Go-Web/internal/metrics"
+	"Scalable-Secure-Go-Web/internal/migrations"
+	"Scalable-Secure-Go-Web/internal/tracing"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -17,20 +20,9 @@ import (
 var DB *gorm.DB
 
 func Connect(cfg *App) {
-	// Configure custom logger for GORM
-	newLogger := logger.New(
-		log.New(os.Stdout, "\r\n[GORM] ", log.LstdFlags),
-		logger.Config{
-			SlowThreshold:             200 * time.Millisecond, // Log slow queries
-			LogLevel:                  logger.Warn,            // Use Info or Error as needed
-			IgnoreRecordNotFoundError: true,
-			Colorful:                  true,
-		},
-	)
-
-	// GORM config with improved safety/logging
+	// GORM statements go to the structured log; slow queries as warnings
 	gormConfig := &gorm.Config{
-		Logger: newLogger,
+		Logger: logging.GormLogger{SlowThreshold: 200 * time.Millisecond},
 		// NamingStrategy: schema.NamingStrategy{}, // Optional: customize naming
 		DisableForeignKeyConstraintWhenMigrating: false, // Let GORM manage FKs
 		// Add more config if needed
@@ -48,21 +40,70 @@ func Connect(cfg *App) {
 		DB, err = gorm.Open(sqlite.Open(cfg.DBDSN), gormConfig)
 
 	default:
-		log.Fatalf("❌ Unsupported DB driver: %s", cfg.DBDriver)
+		logging.Fatal("Unsupported DB driver", "driver", cfg.DBDriver)
 	}
 
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to database (%s): %v", cfg.DBDriver, err)
+		logging.Fatal("Failed to connect to database", "driver", cfg.DBDriver, "error", err)
 	}
 
-	// Run migrations (in correct order)
-	if err := DB.AutoMigrate(
-		&models.Brand{},
-		&models.Category{},
-		&models.Product{},
-	); err != nil {
-		log.Fatalf("❌ Failed to auto-migrate database: %v", err)
+	// Export query durations and pool stats on /metrics, and trace statements
+	if err := DB.Use(metrics.GormPlugin{}); err != nil {
+		logging.Fatal("Failed to register GORM metrics", "error", err)
+	}
+	if err := DB.Use(tracing.GormPlugin{}); err != nil {
+		logging.Fatal("Failed to register GORM tracing", "error", err)
+	}
+	if sqlDB, err := DB.DB(); err == nil {
+		if err := metrics.RegisterDBStats(sqlDB); err != nil {
+			slog.Warn("Failed to register database pool metrics", "error", err)
+		}
 	}
 
-	log.Println("✅ Database connection & migration successful")
+	// Apply pending schema migrations; replicas serialize on the migration lock
+	if cfg.MigrateOnStart {
+		migrator, err := NewMigrator(cfg)
+		if err != nil {
+			logging.Fatal("Failed to load migrations", "error", err)
+		}
+		applied, err := migrator.Up(context.Background())
+		if err != nil && !errors.Is(err, migrations.ErrNoChange) {
+			logging.Fatal("Failed to migrate database", "error", err)
+		}
+		slog.Info("Database connection successful", "driver", cfg.DBDriver, "migrations_applied", applied)
+		return
+	}
+
+	slog.Info("Database connection successful", "driver", cfg.DBDriver, "migrate_on_start", false)
+}
+
+// NewMigrator returns a migrator for the connected database. Connect must have
+// been called first.
+func NewMigrator(cfg *App) (*migrations.Migrator, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	return migrations.New(sqlDB, cfg.DBDriver)
+}
+
+// Ping verifies the database is reachable.
+func Ping(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the database connection pool.
+func Close() error {
+	if DB == nil {
+		return nil
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// redacted replaces secrets in logged values, matching net/url's Redacted.
+const redacted = "xxxxx"
+
+var (
+	// dsnSecretPattern matches password settings in key=value DSNs
+	// ("host=db password=s3cret") and query strings ("?_auth_pass=s3cret").
+	dsnSecretPattern = regexp.MustCompile(`(?i)\b(password|passwd|pwd|_auth_pass)(\s*=\s*)('[^']*'|[^\s&;]*)`)
+
+	// dsnUserinfoPattern matches the credentials of a MySQL DSN
+	// ("user:s3cret@tcp(db:3306)/catalog").
+	dsnUserinfoPattern = regexp.MustCompile(`^([^:@/\s]*):([^@/\s]*)@`)
+)
+
+// RedactDSN masks the password in a Postgres, MySQL or SQLite DSN, in either
+// URL or key=value form, so it can be logged.
+func RedactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" && u.User != nil {
+		dsn = u.Redacted()
+	} else {
+		dsn = dsnUserinfoPattern.ReplaceAllString(dsn, "${1}:"+redacted+"@")
+	}
+	return dsnSecretPattern.ReplaceAllString(dsn, "${1}${2}"+redacted)
+}
+
+// LogValue renders the configuration for the startup log. Secrets (JWT key,
+// cursor secret) are left out and the DSN password is masked.
+func (a *App) LogValue() slog.Value {
+	roles := make([]string, 0, len(a.RolePermissions))
+	for role := range a.RolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+
+	return slog.GroupValue(
+		slog.Int("APP_PORT", a.Port),
+		slog.String("ENVIRONMENT", a.Environment),
+		slog.String("DB_DRIVER", a.DBDriver),
+		slog.String("DB_DSN", RedactDSN(a.DBDSN)),
+		slog.Bool("MIGRATE_ON_START", a.MigrateOnStart),
+		slog.Bool("LOG_TO_FILE", a.LogToFile),
+		slog.String("LOG_FILE", a.LogFile),
+		slog.String("LOG_FILE_MODE", fmt.Sprintf("%#o", a.LogFileMode)),
+		slog.Int64("LOG_MAX_SIZE", a.LogMaxSize),
+		slog.String("LOG_ROTATE_INTERVAL", a.LogRotateInterval.String()),
+		slog.String("LOG_MAX_AGE", a.LogMaxAge.String()),
+		slog.Int("LOG_MAX_FILES", a.LogMaxFiles),
+		slog.Bool("LOG_COMPRESS", a.LogCompress),
+		slog.String("LOG_LEVEL", a.LogLevel),
+		slog.String("LOG_FORMAT", a.LogFormat),
+		slog.String("SHUTDOWN_DELAY", a.ShutdownDelay.String()),
+		slog.String("SHUTDOWN_TIMEOUT", a.ShutdownTimeout.String()),
+		slog.String("HEALTH_CHECK_TIMEOUT", a.HealthCheckTimeout.String()),
+		slog.Bool("METRICS_ENABLED", a.MetricsEnabled),
+		slog.String("I18N_DIR", a.I18nDir),
+		slog.String("TRACING_EXPORTER", a.TracingExporter),
+		slog.Any("FRONTEND_ORIGINS", a.FrontendOrigins),
+		slog.Int("RATE_LIMIT_MAX", a.RateLimitMax),
+		slog.String("RATE_LIMIT_WINDOW", a.RateLimitWindow.String()),
+		slog.Bool("ENABLE_HELMET", a.EnableHelmet),
+		slog.Bool("ENABLE_RATE_LIMITER", a.EnableLimiter),
+		slog.String("RATE_LIMIT_STORE", a.RateLimitStore),
+		slog.Any("TRUSTED_PROXIES", a.TrustedProxies),
+		slog.String("CACHE_CONTROL_PRODUCTS", a.CacheControl["products"]),
+		slog.String("CACHE_CONTROL_CATEGORIES", a.CacheControl["categories"]),
+		slog.String("CACHE_CONTROL_BRANDS", a.CacheControl["brands"]),
+		slog.String("IDEMPOTENCY_TTL", a.IdempotencyTTL.String()),
+		slog.String("IDEMPOTENCY_LOCK_TIMEOUT", a.IdempotencyLockTimeout.String()),
+		slog.Bool("REQUIRE_IF_MATCH", a.RequireIfMatch),
+		slog.Bool("AUTH_ENABLED", a.AuthEnabled),
+		slog.String("JWT_ALGORITHM", a.JWTAlgorithm),
+		slog.Any("RBAC_ROLES", roles),
+	)
+}
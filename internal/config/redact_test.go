@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestRedactDSN(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"postgres://catalog:s3cret@db:5432/catalog?sslmode=disable", "postgres://catalog:xxxxx@db:5432/catalog?sslmode=disable"},
+		{"host=db user=catalog password=s3cret dbname=catalog", "host=db user=catalog password=xxxxx dbname=catalog"},
+		{"host=db password='s3 cret' dbname=catalog", "host=db password=xxxxx dbname=catalog"},
+		{"catalog:s3cret@tcp(db:3306)/catalog?parseTime=true", "catalog:xxxxx@tcp(db:3306)/catalog?parseTime=true"},
+		{"file:catalog.db?_auth&_auth_user=admin&_auth_pass=s3cret", "file:catalog.db?_auth&_auth_user=admin&_auth_pass=xxxxx"},
+		{"catalog.db", "catalog.db"},
+	}
+	for _, tt := range tests {
+		if got := RedactDSN(tt.dsn); got != tt.want {
+			t.Errorf("RedactDSN(%q) = %q, want %q", tt.dsn, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Package exporter streams catalog tables as CSV, NDJSON or XLSX. Records are
+// read from the repositories a page at a time and written as they arrive, so
+// an export of any size runs in constant memory.
+package exporter
+
+import (
+	"Scalable-Secure-Go-Web/internal/repository"
+	"context"
+	"io"
+)
+
+// DefaultPageSize is the number of records read per query.
+const DefaultPageSize = 500
+
+// Options tune one export.
+type Options struct {
+	Format Format
+	// Filter and Sort select and order the products, as on GET /products.
+	// Brands and categories are always exported in ID order.
+	Filter   repository.ProductFilter
+	Sort     []repository.SortField
+	PageSize int // 0 means DefaultPageSize
+}
+
+// Columns of each export. Products carry the name of their category and
+// brand next to the IDs, the same columns a product import reads.
+var (
+	ProductColumns = []string{
+		"id", "name", "description", "price", "cover_image",
+		"category_id", "category", "brand_id", "brand",
+		"version", "created_at", "updated_at",
+	}
+	CategoryColumns = []string{"id", "title", "cover_image", "version", "created_at", "updated_at"}
+	BrandColumns    = []string{"id", "name", "cover_image", "version", "created_at", "updated_at"}
+)
+
+// flusher is implemented by buffered writers such as an HTTP response
+// stream, which are flushed after each page so the client sees progress.
+type flusher interface {
+	Flush() error
+}
+
+// Products writes the products matching opts.Filter to w, with their
+// category and brand, and returns the number of rows written. Pages are read
+// by keyset, so the last page costs no more than the first. If it fails,
+// what was written so far is incomplete.
+func Products(ctx context.Context, w io.Writer, products repository.ProductRepository, opts Options) (int, error) {
+	query := repository.ProductQuery{
+		Page:   repository.Page{Limit: pageSize(opts)},
+		Filter: opts.Filter,
+		Sort:   opts.Sort,
+	}
+	var keyset *repository.Keyset
+	return export(w, opts.Format, "products", ProductColumns, func() ([][]any, error) {
+		page, err := products.ListKeyset(ctx, query, keyset)
+		if err != nil || len(page) == 0 {
+			return nil, err
+		}
+		keyset = &repository.Keyset{Boundary: page[len(page)-1]}
+
+		rows := make([][]any, len(page))
+		for i, p := range page {
+			rows[i] = []any{
+				p.ID, p.Name, p.Description, p.Price, p.CoverImage,
+				p.CategoryID, p.Category.Title, p.BrandID, p.Brand.Name,
+				p.Version, p.CreatedAt, p.UpdatedAt,
+			}
+		}
+		return rows, nil
+	})
+}
+
+// Categories writes every category to w in ID order and returns the number
+// of rows written.
+func Categories(ctx context.Context, w io.Writer, categories repository.CategoryRepository, opts Options) (int, error) {
+	var after uint
+	return export(w, opts.Format, "categories", CategoryColumns, func() ([][]any, error) {
+		page, err := categories.ListAfter(ctx, after, pageSize(opts))
+		if err != nil || len(page) == 0 {
+			return nil, err
+		}
+		after = page[len(page)-1].ID
+
+		rows := make([][]any, len(page))
+		for i, c := range page {
+			rows[i] = []any{c.ID, c.Title, c.CoverImage, c.Version, c.CreatedAt, c.UpdatedAt}
+		}
+		return rows, nil
+	})
+}
+
+// Brands writes every brand to w in ID order and returns the number of rows
+// written.
+func Brands(ctx context.Context, w io.Writer, brands repository.BrandRepository, opts Options) (int, error) {
+	var after uint
+	return export(w, opts.Format, "brands", BrandColumns, func() ([][]any, error) {
+		page, err := brands.ListAfter(ctx, after, pageSize(opts))
+		if err != nil || len(page) == 0 {
+			return nil, err
+		}
+		after = page[len(page)-1].ID
+
+		rows := make([][]any, len(page))
+		for i, b := range page {
+			rows[i] = []any{b.ID, b.Name, b.CoverImage, b.Version, b.CreatedAt, b.UpdatedAt}
+		}
+		return rows, nil
+	})
+}
+
+// export writes the pages returned by next until it returns none.
+func export(w io.Writer, format Format, name string, columns []string, next func() ([][]any, error)) (int, error) {
+	s, err := newSheet(format, w, name, columns)
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for {
+		rows, err := next()
+		if err != nil {
+			return written, err
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			if err := s.row(row); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if err := s.flush(); err != nil {
+			return written, err
+		}
+		if f, ok := w.(flusher); ok {
+			if err := f.Flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, s.close()
+}
+
+func pageSize(opts Options) int {
+	if opts.PageSize > 0 {
+		return opts.PageSize
+	}
+	return DefaultPageSize
+}
@@ -0,0 +1,156 @@
+package exporter
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// seedProducts stores one product per name in a single category and brand.
+func seedProducts(t *testing.T, names ...string) repository.ProductRepository {
+	t.Helper()
+	ctx := context.Background()
+	categories := repository.NewMemoryCategoryRepository()
+	brands := repository.NewMemoryBrandRepository()
+	category := models.Category{Title: "Phones", CoverImage: "https://example.com/phones.png"}
+	if err := categories.Create(ctx, &category); err != nil {
+		t.Fatal(err)
+	}
+	brand := models.Brand{Name: "Acme & Sons", CoverImage: "https://example.com/acme.png"}
+	if err := brands.Create(ctx, &brand); err != nil {
+		t.Fatal(err)
+	}
+
+	products := repository.NewMemoryProductRepository(categories, brands)
+	for _, name := range names {
+		product := models.Product{
+			Name: name, Description: "A phone", Price: 9.5, CoverImage: "https://example.com/phone.png",
+			CategoryID: category.ID, BrandID: brand.ID,
+		}
+		if err := products.Create(ctx, &product); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return products
+}
+
+func TestProductsCSV(t *testing.T) {
+	products := seedProducts(t, "Phone X", "=HYPERLINK(\"evil\")", "Phone Z")
+
+	var out bytes.Buffer
+	rows, err := Products(context.Background(), &out, products, Options{Format: CSV, PageSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Fatalf("rows = %d, want 3", rows)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || strings.Join(records[0], ",") != strings.Join(ProductColumns, ",") {
+		t.Fatalf("CSV has %d records, header %v", len(records), records[0])
+	}
+	// Every page is read once, in ID order, with the names of the references
+	for i, want := range []string{"Phone X", `'=HYPERLINK("evil")`, "Phone Z"} {
+		if got := records[i+1][1]; got != want {
+			t.Errorf("row %d name = %q, want %q", i+1, got, want)
+		}
+	}
+	if records[1][3] != "9.5" || records[1][6] != "Phones" || records[1][8] != "Acme & Sons" {
+		t.Fatalf("row 1 = %v", records[1])
+	}
+}
+
+func TestProductsNDJSON(t *testing.T) {
+	products := seedProducts(t, "Phone <X>")
+
+	var out bytes.Buffer
+	if _, err := Products(context.Background(), &out, products, Options{Format: NDJSON}); err != nil {
+		t.Fatal(err)
+	}
+	line := out.String()
+	if !strings.HasPrefix(line, `{"id":1,"name":"Phone <X>","description":"A phone","price":9.5,`) || !strings.HasSuffix(line, "}\n") {
+		t.Fatalf("NDJSON = %s", line)
+	}
+	var record map[string]any
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["brand"] != "Acme & Sons" || len(record) != len(ProductColumns) {
+		t.Fatalf("record = %v", record)
+	}
+}
+
+func TestProductsXLSX(t *testing.T) {
+	products := seedProducts(t, "Phone X", "Phone <Y>")
+
+	var out bytes.Buffer
+	if _, err := Products(context.Background(), &out, products, Options{Format: XLSX}); err != nil {
+		t.Fatal(err)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := map[string][]byte{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every part must be well-formed XML
+		if err := xml.Unmarshal(data, new(struct{})); err != nil {
+			t.Fatalf("%s: %v", file.Name, err)
+		}
+		parts[file.Name] = data
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatal(err)
+	}
+	if len(sheet.Rows) != 3 {
+		t.Fatalf("sheet has %d rows, want 3", len(sheet.Rows))
+	}
+	if cells := sheet.Rows[2].Cells; cells[0].Value != "2" || cells[1].Inline != "Phone <Y>" || cells[3].Value != "9.5" {
+		t.Fatalf("row 3 = %+v", cells)
+	}
+	if !bytes.Contains(parts["xl/workbook.xml"], []byte(`name="products"`)) {
+		t.Fatalf("workbook = %s", parts["xl/workbook.xml"])
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, s := range []string{"csv", "NDJSON", "xlsx"} {
+		if _, ok := ParseFormat(s); !ok {
+			t.Errorf("ParseFormat(%q) failed", s)
+		}
+	}
+	if _, ok := ParseFormat("xls"); ok {
+		t.Error("ParseFormat accepted xls")
+	}
+}
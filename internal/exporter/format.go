@@ -0,0 +1,172 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the encoding of an export.
+type Format string
+
+const (
+	CSV    Format = "csv"    // a header row, then one record per row
+	NDJSON Format = "ndjson" // one JSON object per line
+	XLSX   Format = "xlsx"   // an Excel workbook with a single sheet
+)
+
+// Media types of the export formats.
+const (
+	MIMECSV    = "text/csv"
+	MIMENDJSON = "application/x-ndjson"
+	MIMEXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// ParseFormat returns the format named by s: csv, ndjson or xlsx.
+func ParseFormat(s string) (Format, bool) {
+	switch f := Format(strings.ToLower(s)); f {
+	case CSV, NDJSON, XLSX:
+		return f, true
+	}
+	return "", false
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case NDJSON:
+		return MIMENDJSON
+	case XLSX:
+		return MIMEXLSX
+	}
+	return MIMECSV + "; charset=utf-8"
+}
+
+// sheet writes the rows of one table. Values are strings, uints, float64s or
+// times; times are written in RFC 3339, in UTC.
+type sheet interface {
+	row(values []any) error
+	// flush pushes the rows written so far to the underlying writer.
+	flush() error
+	// close finishes the file; the sheet cannot be used afterwards.
+	close() error
+}
+
+func newSheet(format Format, w io.Writer, name string, columns []string) (sheet, error) {
+	switch format {
+	case CSV:
+		return newCSVSheet(w, columns)
+	case NDJSON:
+		return &ndjsonSheet{w: w, columns: columns}, nil
+	case XLSX:
+		return newXLSXSheet(w, name, columns)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// csvSheet writes a header row naming the columns, then one record per row.
+type csvSheet struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVSheet(w io.Writer, columns []string) (*csvSheet, error) {
+	s := &csvSheet{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	if err := s.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *csvSheet) row(values []any) error {
+	for i, v := range values {
+		if text, ok := v.(string); ok {
+			s.record[i] = defuseFormula(text)
+			continue
+		}
+		s.record[i] = formatValue(v)
+	}
+	return s.w.Write(s.record)
+}
+
+func (s *csvSheet) flush() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+func (s *csvSheet) close() error {
+	return s.flush()
+}
+
+// defuseFormula prefixes text that a spreadsheet would run as a formula with
+// a quote, so a CSV export opened in Excel cannot execute catalog content.
+func defuseFormula(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// ndjsonSheet writes each row as a JSON object whose keys are the columns, in
+// column order.
+type ndjsonSheet struct {
+	w       io.Writer
+	columns []string
+	buf     bytes.Buffer
+}
+
+func (s *ndjsonSheet) row(values []any) error {
+	s.buf.Reset()
+	s.buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			s.buf.WriteByte(',')
+		}
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC()
+		}
+		if err := s.encode(s.columns[i]); err != nil {
+			return err
+		}
+		s.buf.WriteByte(':')
+		if err := s.encode(v); err != nil {
+			return err
+		}
+	}
+	s.buf.WriteString("}\n")
+	_, err := s.w.Write(s.buf.Bytes())
+	return err
+}
+
+// encode appends v to the buffer without escaping HTML characters, which
+// json.Marshal would turn into \u003c and the like.
+func (s *ndjsonSheet) encode(v any) error {
+	enc := json.NewEncoder(&s.buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	s.buf.Truncate(s.buf.Len() - 1) // the newline Encode ends with
+	return nil
+}
+
+func (s *ndjsonSheet) flush() error { return nil }
+func (s *ndjsonSheet) close() error { return nil }
+
+// formatValue returns the text of a value that is not a string.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
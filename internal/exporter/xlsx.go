@@ -0,0 +1,165 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Limits of an Excel worksheet.
+const (
+	xlsxMaxRows     = 1 << 20
+	xlsxMaxCellText = 32767 // UTF-16 code units
+)
+
+var errTooManyRows = errors.New("the export has more rows than an XLSX sheet holds (1048576)")
+
+// The fixed parts of a workbook with one sheet. Strings are written inline
+// in the sheet, so there is no shared string table to build in memory.
+const (
+	xlsxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`</Types>`
+	xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`
+	xlsxSheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>` +
+		`<sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// xlsxSheet streams a workbook: the fixed parts are written up front, then
+// the worksheet row by row as the last entry of the zip archive. The header
+// row is bold and frozen.
+type xlsxSheet struct {
+	zip  *zip.Writer
+	w    *bufio.Writer // the worksheet entry
+	rows int
+}
+
+func newXLSXSheet(w io.Writer, name string, columns []string) (*xlsxSheet, error) {
+	archive := zip.NewWriter(w)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, escapeXML(name))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		entry, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(entry, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	entry, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	s := &xlsxSheet{zip: archive, w: bufio.NewWriter(entry)}
+	s.w.WriteString(xlsxSheetStart)
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := s.writeRow(header, ` s="1"`); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *xlsxSheet) row(values []any) error {
+	return s.writeRow(values, "")
+}
+
+// writeRow writes one <row>; style is added to each of its cells.
+func (s *xlsxSheet) writeRow(values []any, style string) error {
+	if s.rows == xlsxMaxRows {
+		return errTooManyRows
+	}
+	s.rows++
+
+	s.w.WriteString(`<row r="` + strconv.Itoa(s.rows) + `">`)
+	for _, v := range values {
+		switch v := v.(type) {
+		case uint, float64:
+			s.w.WriteString(`<c` + style + `><v>` + formatValue(v) + `</v></c>`)
+		default:
+			text, _ := v.(string)
+			if t, ok := v.(time.Time); ok {
+				text = formatValue(t)
+			}
+			s.w.WriteString(`<c` + style + ` t="inlineStr"><is><t xml:space="preserve">`)
+			s.w.WriteString(escapeXML(truncateCell(text)))
+			s.w.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := s.w.WriteString(`</row>`)
+	return err
+}
+
+func (s *xlsxSheet) flush() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.zip.Flush()
+}
+
+func (s *xlsxSheet) close() error {
+	s.w.WriteString(xlsxSheetEnd)
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	return s.zip.Close()
+}
+
+// escapeXML escapes text for element content; characters XML cannot carry,
+// such as most control characters, become U+FFFD.
+func escapeXML(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// truncateCell cuts text to the length an Excel cell holds.
+func truncateCell(text string) string {
+	units := 0
+	for i, r := range text {
+		units += utf16.RuneLen(r)
+		if units > xlsxMaxCellText {
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/apikeys"
+	"Scalable-Secure-Go-Web/internal/middleware"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"regexp"
+	"time"
+)
+
+var validateAPIKey = respond.Validator()
+
+// scopePattern accepts "*", "resource:*" and "resource:action".
+var scopePattern = regexp.MustCompile(`^(\*|[a-z]+:(\*|[a-z]+))$`)
+
+// APIKeyHandler serves the /admin/api-keys routes.
+type APIKeyHandler struct {
+	keys repository.APIKeyRepository
+	rbac *middleware.RBAC
+}
+
+// NewAPIKeyHandler returns an APIKeyHandler. rbac is used to stop callers from
+// minting keys with scopes they do not hold themselves.
+func NewAPIKeyHandler(keys repository.APIKeyRepository, rbac *middleware.RBAC) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, rbac: rbac}
+}
+
+// GetAllAPIKeys godoc
+// @Summary List API keys
+// @Description Retrieve API key metadata (never the secrets)
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page (max 100)"
+// @Success 200 {object} models.APIResponse{data=[]models.APIKey}
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) GetAllAPIKeys(c *fiber.Ctx) error {
+	pager := parsePagination(c)
+
+	keys, total, err := h.keys.List(c.UserContext(), pager.window())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch API keys", err)
+	}
+
+	return c.JSON(models.APIResponse{
+		Status:     "success",
+		StatusCode: 200,
+		Data:       keys,
+		Message:    "API keys retrieved successfully",
+		Meta:       pager.meta(c, total),
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Create a scoped API key. The plaintext key is only returned in this response.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param key body models.APIKeyInput true "API key JSON"
+// @Success 201 {object} models.APIResponse{data=models.APIKeyCreated}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var input models.APIKeyInput
+
+	// Parse JSON body
+	if err := c.BodyParser(&input); err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
+	}
+
+	// Validate input
+	if err := validateAPIKey.Struct(input); err != nil {
+		return respond.ValidationFailed(c, err)
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return respond.Invalid(c, models.FieldError{Field: "expires_at", Rule: "future", Message: "expires_at must be in the future"})
+	}
+	for i, scope := range input.Scopes {
+		if !scopePattern.MatchString(scope) {
+			return respond.Invalid(c, models.FieldError{
+				Field:   fmt.Sprintf("scopes[%d]", i),
+				Rule:    "scope",
+				Param:   scope,
+				Message: "Invalid scope '{0}'",
+			})
+		}
+	}
+
+	// A caller can only delegate permissions it holds
+	if principal, ok := middleware.PrincipalFrom(c); ok {
+		for _, scope := range input.Scopes {
+			if !h.rbac.Allowed(principal, scope) {
+				return respond.Error(c, fiber.StatusForbidden, "Cannot grant scope {0}", scope)
+			}
+		}
+	}
+
+	plaintext, prefix, hash, err := apikeys.Generate()
+	if err != nil {
+		return respond.ServerError(c, "Failed to generate API key", err)
+	}
+
+	key := models.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		Hash:      hash,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := h.keys.Create(c.UserContext(), &key); err != nil {
+		return respond.ServerError(c, "Failed to create API key", err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status:     "success",
+		StatusCode: 201,
+		Data:       models.APIKeyCreated{APIKey: key, Key: plaintext},
+		Message:    "API key created successfully. Store it now; it will not be shown again",
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key by ID. Revoked keys stay listed for auditing.
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 204
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid API key ID")
+	}
+
+	if err := h.keys.Revoke(c.UserContext(), id, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "API key not found")
+		}
+		return respond.ServerError(c, "Failed to revoke API key", err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
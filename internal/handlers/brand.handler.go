@@ -1,38 +1,91 @@
 package handlers
 
 import (
-	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/exporter"
 	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
+	"io"
 )
 
+// BrandHandler serves the /brands routes.
+type BrandHandler struct {
+	brands repository.BrandRepository
+}
+
+// NewBrandHandler returns a BrandHandler backed by the given repository.
+func NewBrandHandler(brands repository.BrandRepository) *BrandHandler {
+	return &BrandHandler{brands: brands}
+}
+
 // GetAllBrands godoc
 // @Summary Get all brands
 // @Description Retrieve all brands
 // @Tags Brands
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page (max 100)"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "When any brand last changed, deletions included"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 500 {object} models.Problem
 // @Router /brands [get]
-func GetAllBrands(c *fiber.Ctx) error {
-	var brands []models.Brand
+func (h *BrandHandler) GetAllBrands(c *fiber.Ctx) error {
+	pager := parsePagination(c)
 
-	if err := config.DB.Find(&brands).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to fetch brands",
-		})
+	// Read before the page, so a concurrent write can only make it look older
+	modified, err := h.brands.Modified(c.UserContext())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch brands", err)
 	}
 
-	return c.JSON(models.APIResponse{
+	brands, total, err := h.brands.List(c.UserContext(), pager.window())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch brands", err)
+	}
+
+	return sendCacheable(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       brands,
 		Message:    "Brands retrieved successfully",
+		Meta:       pager.meta(c, total),
+	}, modified)
+}
+
+// ExportBrands godoc
+// @Summary Export brands as CSV, NDJSON or XLSX
+// @Description Stream every brand in ID order as a file download. Columns: id, name, cover_image, version, created_at, updated_at.
+// @Tags Brands
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Success 200 {file} file
+// @Header 200 {string} Content-Disposition "Attachment named after the resource and date, e.g. brands-20250709.csv"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /brands/export [get]
+func (h *BrandHandler) ExportBrands(c *fiber.Ctx) error {
+	format, ok := exportFormat(c)
+	if !ok {
+		return badExportFormat(c)
+	}
+
+	return sendExport(c, "brands", format, func(ctx context.Context, w io.Writer) (int, error) {
+		return exporter.Brands(ctx, w, h.brands, exporter.Options{Format: format})
 	})
 }
 
@@ -43,36 +96,36 @@ func GetAllBrands(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Brand ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "Most recent updated_at in the response"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
 // @Router /brands/{id} [get]
-func GetBrandByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var brand models.Brand
+func (h *BrandHandler) GetBrandByID(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid brand ID")
+	}
 
-	if err := config.DB.First(&brand, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-				Status:     "error",
-				StatusCode: 404,
-				Data:       nil,
-				Message:    "Brand not found",
-			})
+	brand, err := h.brands.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Brand not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Error retrieving brand",
-		})
+		return respond.ServerError(c, "Error retrieving brand", err)
 	}
 
-	return c.JSON(models.APIResponse{
+	return sendVersioned(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       brand,
 		Message:    "Brand retrieved successfully",
-	})
+	}, brandETag(brand), brand.UpdatedAt)
 }
 
 // CreateBrand godoc
@@ -82,41 +135,33 @@ func GetBrandByID(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param brand body models.Brand true "Brand JSON"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
 // @Success 201 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /brands [post]
-func CreateBrand(c *fiber.Ctx) error {
+func (h *BrandHandler) CreateBrand(c *fiber.Ctx) error {
 	var brand models.Brand
 
 	// Parse body
 	if err := c.BodyParser(&brand); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid request body",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
 	}
 
 	// Validate input
 	if err := validateBrand.Struct(&brand); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	// Insert into DB
-	if err := config.DB.Create(&brand).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to create brand",
-		})
+	if err := h.brands.Create(c.UserContext(), &brand); err != nil {
+		return respond.ServerError(c, "Failed to create brand", err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
@@ -134,57 +179,114 @@ func CreateBrand(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Brand ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Param brand body models.Brand true "Brand JSON"
 // @Success 200 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /brands/{id} [put]
-func UpdateBrand(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var existing models.Brand
+func (h *BrandHandler) UpdateBrand(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid brand ID")
+	}
 
 	// Check existence
-	if err := config.DB.First(&existing, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Brand not found",
-		})
+	existing, err := h.brands.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Brand not found")
+		}
+		return respond.ServerError(c, "Error retrieving brand", err)
+	}
+
+	if current := brandETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
 	var input models.Brand
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid input",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
 	}
 
+	return h.update(c, existing, input)
+}
+
+// PatchBrand godoc
+// @Summary Partially update a brand
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or JSON Patch (application/json-patch+json) to a brand. Only the patched document is validated.
+// @Tags Brands
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Brand ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /brands/{id} [patch]
+func (h *BrandHandler) PatchBrand(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid brand ID")
+	}
+
+	existing, err := h.brands.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Brand not found")
+		}
+		return respond.ServerError(c, "Error retrieving brand", err)
+	}
+
+	if current := brandETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
+	}
+
+	var input models.Brand
+	if err := applyPatch(c, existing, &input); err != nil {
+		return patchFailed(c, err)
+	}
+
+	return h.update(c, existing, input)
+}
+
+// update validates input, copies its writable fields onto existing and saves
+// it. It backs both PUT and PATCH.
+func (h *BrandHandler) update(c *fiber.Ctx, existing *models.Brand, input models.Brand) error {
 	if err := validateBrand.Struct(input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	// Update fields
 	existing.Name = input.Name
 	existing.CoverImage = input.CoverImage
 
-	if err := config.DB.Save(&existing).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to update brand",
-		})
+	if err := h.brands.Update(c.UserContext(), existing); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to update brand", err)
 	}
 
+	c.Set(fiber.HeaderETag, brandETag(existing))
 	return c.JSON(models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
@@ -200,30 +302,233 @@ func UpdateBrand(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Brand ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Success 204
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /brands/{id} [delete]
-func DeleteBrand(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var brand models.Brand
+func (h *BrandHandler) DeleteBrand(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid brand ID")
+	}
+
+	existing, err := h.brands.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Brand not found")
+		}
+		return respond.ServerError(c, "Error retrieving brand", err)
+	}
 
-	if err := config.DB.First(&brand, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Brand not found",
-		})
+	if current := brandETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
-	if err := config.DB.Delete(&brand).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to delete brand",
-		})
+	if err := h.brands.Delete(c.UserContext(), id, existing.Version); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return respond.Error(c, fiber.StatusNotFound, "Brand not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to delete brand", err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// BulkCreateBrands godoc
+// @Summary Create brands in bulk
+// @Description Create up to 1000 brands from a JSON array. In atomic mode (the default) nothing is created unless every item is valid, and the problem lists each failed item's errors under "[index]". In partial mode the valid items are created and each item's outcome is reported, with 207 if any failed.
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param brands body []models.Brand true "Brands to create"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
+// @Success 201 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /brands/bulk [post]
+func (h *BrandHandler) BulkCreateBrands(c *fiber.Ctx) error {
+	var brands []models.Brand
+	atomic, err := parseBulk(c, &brands)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	results := newBulkResults(c, atomic, len(brands))
+	for i := range brands {
+		results.validate(i, validateBrand.Struct(brands[i]))
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.brands.CreateMany(c.UserContext(), brands); err != nil {
+			return respond.ServerError(c, "Failed to create brands", err)
+		}
+	}
+
+	for i := range brands {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.brands.Create(c.UserContext(), &brands[i]); err != nil {
+				results.serverError(i, "Failed to create brand", err)
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusCreated, brands[i].ID, brands[i])
+	}
+
+	return results.send(fiber.StatusCreated, "Brands created successfully")
+}
+
+// BulkUpdateBrands godoc
+// @Summary Update brands in bulk
+// @Description Replace up to 1000 brands, each identified by its id. A non-zero version must be the brand's current one, like If-Match. Modes are as for bulk create.
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param brands body []models.Brand true "Brands to update"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /brands/bulk [put]
+func (h *BrandHandler) BulkUpdateBrands(c *fiber.Ctx) error {
+	var inputs []models.Brand
+	atomic, err := parseBulk(c, &inputs)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(inputs))
+	versions := make([]uint, len(inputs))
+	for i, input := range inputs {
+		ids[i], versions[i] = input.ID, input.Version
+	}
+	stored, err := h.brands.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving brands", err)
+	}
+	byID := make(map[uint]models.Brand, len(stored))
+	current := make(map[uint]uint, len(stored))
+	for _, brand := range stored {
+		byID[brand.ID] = brand
+		current[brand.ID] = brand.Version
+	}
+
+	results := newBulkResults(c, atomic, len(inputs))
+	results.checkTargets(ids, versions, current, "Brand not found")
+
+	// brands[i] is the stored brand with inputs[i]'s writable fields
+	brands := make([]models.Brand, len(inputs))
+	for i, input := range inputs {
+		if results.failed(i) {
+			continue
+		}
+		results.validate(i, validateBrand.Struct(input))
+		brands[i] = byID[input.ID]
+		brands[i].Name = input.Name
+		brands[i].CoverImage = input.CoverImage
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.brands.UpdateMany(c.UserContext(), brands); err != nil {
+			return results.writeFailed(err, "Brand not found", "Failed to update brands")
+		}
+	}
+
+	for i := range brands {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.brands.Update(c.UserContext(), &brands[i]); err != nil {
+				if !results.lostRace(i, err, "Brand not found") {
+					results.serverError(i, "Failed to update brand", err)
+				}
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusOK, brands[i].ID, brands[i])
+	}
+
+	return results.send(fiber.StatusOK, "Brands updated successfully")
+}
+
+// BulkDeleteBrands godoc
+// @Summary Delete brands in bulk
+// @Description Delete up to 1000 brands by id. A non-zero version must be the brand's current one, like If-Match. Modes are as for bulk create.
+// @Tags Brands
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param brands body []models.BulkDelete true "Brands to delete"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /brands/bulk [delete]
+func (h *BrandHandler) BulkDeleteBrands(c *fiber.Ctx) error {
+	var items []models.BulkDelete
+	atomic, err := parseBulk(c, &items)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(items))
+	versions := make([]uint, len(items))
+	for i, item := range items {
+		ids[i], versions[i] = item.ID, item.Version
+	}
+	stored, err := h.brands.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving brands", err)
+	}
+	current := make(map[uint]uint, len(stored))
+	for _, brand := range stored {
+		current[brand.ID] = brand.Version
+	}
+
+	results := newBulkResults(c, atomic, len(items))
+	results.checkTargets(ids, versions, current, "Brand not found")
+	return deleteBulk(results, items, h.brands, "Brand not found", "Failed to delete brand", "Brands deleted successfully")
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBrandCRUD(t *testing.T) {
+	api := newTestAPI(t)
+
+	resp, body := api.do(http.MethodPost, "/api/v1/brands", fiber.Map{"name": "Acme", "cover_image": "https://example.com/acme.png"})
+	api.expect(resp, body, fiber.StatusCreated)
+	created := decodeData[models.Brand](t, body)
+	path := fmt.Sprintf("/api/v1/brands/%d", created.ID)
+
+	resp, body = api.do(http.MethodGet, path, nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if etag := resp.Header.Get(fiber.HeaderETag); etag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", etag)
+	}
+
+	resp, body = api.do(http.MethodPut, path, fiber.Map{"name": "Acme Corp", "cover_image": "https://example.com/acme.png"},
+		fiber.HeaderIfMatch, `"1"`)
+	api.expect(resp, body, fiber.StatusOK)
+	resp, body = api.do(http.MethodPatch, path, `{"cover_image":"https://example.com/acme-corp.png"}`,
+		fiber.HeaderContentType, "application/merge-patch+json", fiber.HeaderIfMatch, `"1"`)
+	api.expect(resp, body, fiber.StatusPreconditionFailed)
+	resp, body = api.do(http.MethodPatch, path, `{"cover_image":"https://example.com/acme-corp.png"}`,
+		fiber.HeaderContentType, "application/merge-patch+json", fiber.HeaderIfMatch, `"2"`)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[models.Brand](t, body); got.Name != "Acme Corp" || got.CoverImage != "https://example.com/acme-corp.png" || got.Version != 3 {
+		t.Fatalf("patched brand = %+v", got)
+	}
+
+	resp, body = api.do(http.MethodGet, "/api/v1/brands", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Brand](t, body); len(got) != 1 || got[0].Name != "Acme Corp" || got[0].Version != 3 {
+		t.Fatalf("list = %+v", got)
+	}
+	if meta := decodeMeta(t, body); meta.Total == nil || *meta.Total != 1 || *meta.TotalPages != 1 {
+		t.Fatalf("meta = %+v, want one brand on one page", meta)
+	}
+
+	resp, body = api.do(http.MethodDelete, path, nil)
+	api.expect(resp, body, fiber.StatusNoContent)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		resp, body = api.do(method, path, nil)
+		api.expect(resp, body, fiber.StatusNotFound)
+	}
+}
+
+func TestBrandValidation(t *testing.T) {
+	api := newTestAPI(t)
+
+	resp, body := api.do(http.MethodPost, "/api/v1/brands", fiber.Map{"name": "A", "cover_image": "nope"})
+	api.expect(resp, body, fiber.StatusBadRequest)
+	want := map[string]string{"name": "min", "cover_image": "url"}
+	if got := fieldRules(decodeProblem(t, body)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("field errors = %v, want %v", got, want)
+	}
+
+	resp, body = api.do(http.MethodPost, "/api/v1/brands", fiber.Map{"name": "Acme", "cover_image": "https://example.com/acme.png"})
+	api.expect(resp, body, fiber.StatusCreated)
+	created := decodeData[models.Brand](t, body)
+	resp, body = api.do(http.MethodPatch, fmt.Sprintf("/api/v1/brands/%d", created.ID), `{"name":null}`,
+		fiber.HeaderContentType, "application/merge-patch+json")
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if rules := fieldRules(decodeProblem(t, body)); rules["name"] != "required" {
+		t.Fatalf("field errors = %v, want name required", rules)
+	}
+}
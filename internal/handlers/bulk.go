@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"strconv"
+)
+
+// maxBulkItems caps the number of items in one bulk request.
+const maxBulkItems = 1000
+
+// Bulk request modes, chosen with ?mode=.
+const (
+	bulkAtomic  = "atomic"  // every item is written or none is
+	bulkPartial = "partial" // valid items are written, the others reported
+)
+
+var (
+	errBulkMode  = errors.New("unknown bulk mode")
+	errBulkEmpty = errors.New("bulk request has no items")
+	errBulkSize  = errors.New("bulk request has too many items")
+)
+
+// parseBulk decodes the JSON array body into items and reports whether the
+// request is atomic, which is the default.
+func parseBulk[T any](c *fiber.Ctx, items *[]T) (atomic bool, err error) {
+	switch c.Query("mode", bulkAtomic) {
+	case bulkAtomic:
+		atomic = true
+	case bulkPartial:
+	default:
+		return false, errBulkMode
+	}
+
+	if err := c.BodyParser(items); err != nil {
+		return false, err
+	}
+	switch {
+	case len(*items) == 0:
+		return false, errBulkEmpty
+	case len(*items) > maxBulkItems:
+		return false, errBulkSize
+	}
+	return atomic, nil
+}
+
+// bulkRejected writes the problem for an error returned by parseBulk.
+func bulkRejected(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errBulkMode):
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery,
+			"mode must be {0} or {1}", bulkAtomic, bulkPartial)
+	case errors.Is(err, errBulkEmpty):
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "The request has no items")
+	case errors.Is(err, errBulkSize):
+		return respond.Problem(c, fiber.StatusRequestEntityTooLarge, respond.CodePayloadTooLarge,
+			"A bulk request takes at most {0} items", strconv.Itoa(maxBulkItems))
+	}
+	return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
+}
+
+// bulkDeleter is the part of a repository a bulk delete writes through.
+type bulkDeleter interface {
+	Delete(ctx context.Context, id, version uint) error
+	DeleteMany(ctx context.Context, items []models.BulkDelete) error
+}
+
+// bulkResults collects the outcome of each item of a bulk request. Failure
+// details are translated as they are recorded; an item keeps its first
+// failure.
+type bulkResults struct {
+	c     *fiber.Ctx
+	mode  string
+	items []models.BulkItemResult
+}
+
+func newBulkResults(c *fiber.Ctx, atomic bool, n int) *bulkResults {
+	r := &bulkResults{c: c, mode: bulkPartial, items: make([]models.BulkItemResult, n)}
+	if atomic {
+		r.mode = bulkAtomic
+	}
+	for i := range r.items {
+		r.items[i].Index = i
+	}
+	return r
+}
+
+func (r *bulkResults) failed(i int) bool {
+	return r.items[i].Code != ""
+}
+
+func (r *bulkResults) anyFailed() bool {
+	for i := range r.items {
+		if r.failed(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// ok records item i as written. data may be nil, as for deletes.
+func (r *bulkResults) ok(i, status int, id uint, data any) {
+	r.items[i].Status = status
+	r.items[i].ID = id
+	r.items[i].Data = data
+}
+
+// fail records item i as failed with the given problem; detail is a
+// translation key.
+func (r *bulkResults) fail(i, status int, code, detail string, params ...string) {
+	if r.failed(i) {
+		return
+	}
+	trans := i18n.FromContext(r.c.UserContext())
+	r.items[i].Status = status
+	r.items[i].Code = code
+	r.items[i].Detail = i18n.T(trans, detail, params...)
+}
+
+// invalid records item i as failing validation. Each Message is a
+// translation key, as for respond.Invalid.
+func (r *bulkResults) invalid(i int, fields ...models.FieldError) {
+	respond.Localize(r.c, fields)
+	r.invalidFields(i, fields)
+}
+
+// validate records the error Validator returned for item i, if any.
+func (r *bulkResults) validate(i int, err error) {
+	if err == nil {
+		return
+	}
+	fields, ok := respond.FieldErrors(r.c, err)
+	if !ok {
+		r.fail(i, fiber.StatusBadRequest, respond.CodeBadRequest, err.Error())
+		return
+	}
+	r.invalidFields(i, fields)
+}
+
+func (r *bulkResults) invalidFields(i int, fields []models.FieldError) {
+	if r.failed(i) {
+		return
+	}
+	detail := i18n.T(i18n.FromContext(r.c.UserContext()), "The request has invalid fields")
+	if len(fields) == 1 {
+		detail = fields[0].Message
+	}
+	r.items[i].Status = fiber.StatusBadRequest
+	r.items[i].Code = respond.CodeValidationFailed
+	r.items[i].Detail = detail
+	r.items[i].Errors = fields
+}
+
+// serverError logs err and records item i as an internal error. err itself
+// is never sent to the client.
+func (r *bulkResults) serverError(i int, detail string, err error) {
+	slog.ErrorContext(r.c.UserContext(), detail, "item", i, "error", err)
+	r.fail(i, fiber.StatusInternalServerError, respond.CodeInternal, detail)
+}
+
+// checkTargets fails the items of an update or delete whose id is missing,
+// repeated or unknown, or whose non-zero version is not the stored one.
+// stored maps the IDs that exist to their current versions.
+func (r *bulkResults) checkTargets(ids, versions []uint, stored map[uint]uint, notFound string) {
+	seen := make(map[uint]bool, len(ids))
+	for i, id := range ids {
+		current, exists := stored[id]
+		switch {
+		case id == 0:
+			r.invalid(i, models.FieldError{Field: "id", Rule: "required", Message: "id is required"})
+		case seen[id]:
+			r.invalid(i, models.FieldError{Field: "id", Rule: "unique", Message: "id appears more than once in the request"})
+		case !exists:
+			r.fail(i, fiber.StatusNotFound, respond.CodeNotFound, notFound)
+		case versions[i] != 0 && versions[i] != current:
+			r.fail(i, fiber.StatusPreconditionFailed, respond.CodePreconditionFailed,
+				"The resource has changed since it was fetched")
+		}
+		seen[id] = true
+	}
+}
+
+// lostRace records item i as failed if err shows that another request
+// changed or deleted its record after the checks.
+func (r *bulkResults) lostRace(i int, err error, notFound string) bool {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		r.fail(i, fiber.StatusNotFound, respond.CodeNotFound, notFound)
+	case errors.Is(err, repository.ErrVersionConflict):
+		r.fail(i, fiber.StatusConflict, respond.CodeConflict, "The resource was modified concurrently, fetch it and retry")
+	default:
+		return false
+	}
+	return true
+}
+
+// writeFailed answers an atomic write that was rolled back. An item that lost
+// a race rejects the request like a failed check; anything else is a server
+// error.
+func (r *bulkResults) writeFailed(err error, notFound, detail string) error {
+	var batchErr *repository.BatchError
+	if errors.As(err, &batchErr) && r.lostRace(batchErr.Index, batchErr.Err, notFound) {
+		return r.reject()
+	}
+	return respond.ServerError(r.c, detail, err)
+}
+
+// reject writes the problem for an atomic request in which items failed. It
+// takes the status and code of the first failed item and lists the errors of
+// every failed item under its index: "[3].price", or "[3]" for an item
+// without field errors.
+func (r *bulkResults) reject() error {
+	var first *models.BulkItemResult
+	var fields []models.FieldError
+	failed := 0
+	for i := range r.items {
+		item := &r.items[i]
+		if !r.failed(i) {
+			continue
+		}
+		failed++
+		if first == nil {
+			first = item
+		}
+
+		prefix := "[" + strconv.Itoa(i) + "]"
+		if len(item.Errors) == 0 {
+			fields = append(fields, models.FieldError{Field: prefix, Rule: item.Code, Message: item.Detail})
+		}
+		for _, field := range item.Errors {
+			field.Field = prefix + "." + field.Field
+			fields = append(fields, field)
+		}
+	}
+
+	if failed == 1 {
+		return respond.Rejected(r.c, first.Status, first.Code, fields,
+			"Item {0}: {1}", strconv.Itoa(first.Index), first.Detail)
+	}
+	return respond.Rejected(r.c, first.Status, first.Code, fields,
+		"{0} of {1} items failed; nothing was saved", strconv.Itoa(failed), strconv.Itoa(len(r.items)))
+}
+
+// send writes the results with status, or 207 Multi-Status if any item
+// failed.
+func (r *bulkResults) send(status int, message string) error {
+	result := models.BulkResult{Mode: r.mode, Items: r.items}
+	for i := range r.items {
+		if r.failed(i) {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+	}
+	if result.Failed > 0 {
+		status = fiber.StatusMultiStatus
+		message = i18n.T(i18n.FromContext(r.c.UserContext()), "{0} of {1} items failed",
+			strconv.Itoa(result.Failed), strconv.Itoa(len(r.items)))
+	}
+
+	return r.c.Status(status).JSON(models.APIResponse{
+		Status:     "success",
+		StatusCode: status,
+		Data:       result,
+		Message:    message,
+	})
+}
+
+// deleteBulk deletes the items that passed the checks already recorded in
+// results, in one transaction if the request is atomic, and sends the
+// results. notFound and failed are the resource's detail keys.
+func deleteBulk(results *bulkResults, items []models.BulkDelete, repo bulkDeleter, notFound, failed, message string) error {
+	c := results.c
+	atomic := results.mode == bulkAtomic
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := repo.DeleteMany(c.UserContext(), items); err != nil {
+			return results.writeFailed(err, notFound, failed)
+		}
+	}
+
+	for i, item := range items {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := repo.Delete(c.UserContext(), item.ID, item.Version); err != nil {
+				if !results.lostRace(i, err, notFound) {
+					results.serverError(i, failed, err)
+				}
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusNoContent, item.ID, nil)
+	}
+
+	return results.send(fiber.StatusOK, message)
+}
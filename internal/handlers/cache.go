@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"time"
+)
+
+// sendCacheable writes body as a 200 JSON response with a strong ETag that
+// hashes the exact bytes sent, plus Last-Modified, or a bodiless 304 when the
+// client's If-None-Match / If-Modified-Since show it already has them. List
+// and search endpoints use it; their pages have no single version to tag.
+// lastModified must move whenever any page could change, so it is when the
+// listed tables last changed, deletions included (see Modified on the
+// repositories), not the newest updated_at on the page.
+func sendCacheable(c *fiber.Ctx, body any, lastModified time.Time) error {
+	data, err := c.App().Config().JSONEncoder(body)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(data)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	return sendConditional(c, data, tag, lastModified)
+}
+
+// sendVersioned is sendCacheable for a single resource, whose ETag is its
+// version tag so the same value works in If-Match.
+func sendVersioned(c *fiber.Ctx, body any, tag string, lastModified time.Time) error {
+	data, err := c.App().Config().JSONEncoder(body)
+	if err != nil {
+		return err
+	}
+	return sendConditional(c, data, tag, lastModified)
+}
+
+func sendConditional(c *fiber.Ctx, data []byte, tag string, lastModified time.Time) error {
+	c.Set(fiber.HeaderETag, tag)
+	if !lastModified.IsZero() {
+		c.Set(fiber.HeaderLastModified, lastModified.UTC().Format(http.TimeFormat))
+	}
+	if notModified(c, tag, lastModified) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Status(fiber.StatusOK).Send(data)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, as RFC 9110 section 13.2.2 orders them. A zero lastModified ignores
+// If-Modified-Since.
+func notModified(c *fiber.Ctx, tag string, lastModified time.Time) bool {
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" {
+		return matchETag(header, tag, true)
+	}
+	if header := c.Get(fiber.HeaderIfModifiedSince); header != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(header)
+		// HTTP dates have one-second resolution
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// latest returns the most recent of ts, or the zero time if there are none.
+func latest(ts ...time.Time) time.Time {
+	var max time.Time
+	for _, t := range ts {
+		if t.After(max) {
+			max = t
+		}
+	}
+	return max
+}
+
+// productModified is when a product's representation, embedded brand and
+// category included, last changed.
+func productModified(p *models.Product) time.Time {
+	return latest(p.UpdatedAt, p.Brand.UpdatedAt, p.Category.UpdatedAt)
+}
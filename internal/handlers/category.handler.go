@@ -1,38 +1,91 @@
 package handlers
 
 import (
-	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/exporter"
 	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
+	"io"
 )
 
+// CategoryHandler serves the /categories routes.
+type CategoryHandler struct {
+	categories repository.CategoryRepository
+}
+
+// NewCategoryHandler returns a CategoryHandler backed by the given repository.
+func NewCategoryHandler(categories repository.CategoryRepository) *CategoryHandler {
+	return &CategoryHandler{categories: categories}
+}
+
 // GetAllCategories godoc
 // @Summary Get all categories
 // @Description Retrieve a list of all product categories
 // @Tags Categories
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page (max 100)"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "When any category last changed, deletions included"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 500 {object} models.Problem
 // @Router /categories [get]
-func GetAllCategories(c *fiber.Ctx) error {
-	var categories []models.Category
+func (h *CategoryHandler) GetAllCategories(c *fiber.Ctx) error {
+	pager := parsePagination(c)
 
-	if err := config.DB.Find(&categories).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to fetch categories",
-		})
+	// Read before the page, so a concurrent write can only make it look older
+	modified, err := h.categories.Modified(c.UserContext())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch categories", err)
 	}
 
-	return c.JSON(models.APIResponse{
+	categories, total, err := h.categories.List(c.UserContext(), pager.window())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch categories", err)
+	}
+
+	return sendCacheable(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       categories,
 		Message:    "Categories retrieved successfully",
+		Meta:       pager.meta(c, total),
+	}, modified)
+}
+
+// ExportCategories godoc
+// @Summary Export categories as CSV, NDJSON or XLSX
+// @Description Stream every category in ID order as a file download. Columns: id, title, cover_image, version, created_at, updated_at.
+// @Tags Categories
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Success 200 {file} file
+// @Header 200 {string} Content-Disposition "Attachment named after the resource and date, e.g. categories-20250709.csv"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /categories/export [get]
+func (h *CategoryHandler) ExportCategories(c *fiber.Ctx) error {
+	format, ok := exportFormat(c)
+	if !ok {
+		return badExportFormat(c)
+	}
+
+	return sendExport(c, "categories", format, func(ctx context.Context, w io.Writer) (int, error) {
+		return exporter.Categories(ctx, w, h.categories, exporter.Options{Format: format})
 	})
 }
 
@@ -43,36 +96,36 @@ func GetAllCategories(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "Most recent updated_at in the response"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
 // @Router /categories/{id} [get]
-func GetCategoryByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var category models.Category
+func (h *CategoryHandler) GetCategoryByID(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid category ID")
+	}
 
-	if err := config.DB.First(&category, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-				Status:     "error",
-				StatusCode: 404,
-				Data:       nil,
-				Message:    "Category not found",
-			})
+	category, err := h.categories.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Category not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Error retrieving category",
-		})
+		return respond.ServerError(c, "Error retrieving category", err)
 	}
 
-	return c.JSON(models.APIResponse{
+	return sendVersioned(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       category,
 		Message:    "Category retrieved successfully",
-	})
+	}, categoryETag(category), category.UpdatedAt)
 }
 
 // CreateCategory godoc
@@ -82,41 +135,33 @@ func GetCategoryByID(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param category body models.Category true "Category JSON"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
 // @Success 201 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /categories [post]
-func CreateCategory(c *fiber.Ctx) error {
+func (h *CategoryHandler) CreateCategory(c *fiber.Ctx) error {
 	var category models.Category
 
 	// Parse JSON body
 	if err := c.BodyParser(&category); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid request body",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
 	}
 
 	// Validate input
 	if err := validateCategory.Struct(&category); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	// Insert category into DB
-	if err := config.DB.Create(&category).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to create category",
-		})
+	if err := h.categories.Create(c.UserContext(), &category); err != nil {
+		return respond.ServerError(c, "Failed to create category", err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
@@ -134,55 +179,112 @@ func CreateCategory(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Param category body models.Category true "Category JSON"
 // @Success 200 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /categories/{id} [put]
-func UpdateCategory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var existing models.Category
+func (h *CategoryHandler) UpdateCategory(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid category ID")
+	}
+
+	existing, err := h.categories.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Category not found")
+		}
+		return respond.ServerError(c, "Error retrieving category", err)
+	}
 
-	if err := config.DB.First(&existing, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Category not found",
-		})
+	if current := categoryETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
 	var input models.Category
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid input",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
 	}
 
+	return h.update(c, existing, input)
+}
+
+// PatchCategory godoc
+// @Summary Partially update a category
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or JSON Patch (application/json-patch+json) to a category. Only the patched document is validated.
+// @Tags Categories
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /categories/{id} [patch]
+func (h *CategoryHandler) PatchCategory(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid category ID")
+	}
+
+	existing, err := h.categories.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Category not found")
+		}
+		return respond.ServerError(c, "Error retrieving category", err)
+	}
+
+	if current := categoryETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
+	}
+
+	var input models.Category
+	if err := applyPatch(c, existing, &input); err != nil {
+		return patchFailed(c, err)
+	}
+
+	return h.update(c, existing, input)
+}
+
+// update validates input, copies its writable fields onto existing and saves
+// it. It backs both PUT and PATCH.
+func (h *CategoryHandler) update(c *fiber.Ctx, existing *models.Category, input models.Category) error {
 	if err := validateCategory.Struct(input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	existing.Title = input.Title
 	existing.CoverImage = input.CoverImage
 
-	if err := config.DB.Save(&existing).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to update category",
-		})
+	if err := h.categories.Update(c.UserContext(), existing); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to update category", err)
 	}
 
+	c.Set(fiber.HeaderETag, categoryETag(existing))
 	return c.JSON(models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
@@ -198,30 +300,233 @@ func UpdateCategory(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Success 204
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /categories/{id} [delete]
-func DeleteCategory(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var category models.Category
+func (h *CategoryHandler) DeleteCategory(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid category ID")
+	}
+
+	existing, err := h.categories.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Category not found")
+		}
+		return respond.ServerError(c, "Error retrieving category", err)
+	}
 
-	if err := config.DB.First(&category, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Category not found",
-		})
+	if current := categoryETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
-	if err := config.DB.Delete(&category).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to delete category",
-		})
+	if err := h.categories.Delete(c.UserContext(), id, existing.Version); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return respond.Error(c, fiber.StatusNotFound, "Category not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to delete category", err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// BulkCreateCategories godoc
+// @Summary Create categories in bulk
+// @Description Create up to 1000 categories from a JSON array. In atomic mode (the default) nothing is created unless every item is valid, and the problem lists each failed item's errors under "[index]". In partial mode the valid items are created and each item's outcome is reported, with 207 if any failed.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param categories body []models.Category true "Categories to create"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
+// @Success 201 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /categories/bulk [post]
+func (h *CategoryHandler) BulkCreateCategories(c *fiber.Ctx) error {
+	var categories []models.Category
+	atomic, err := parseBulk(c, &categories)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	results := newBulkResults(c, atomic, len(categories))
+	for i := range categories {
+		results.validate(i, validateCategory.Struct(categories[i]))
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.categories.CreateMany(c.UserContext(), categories); err != nil {
+			return respond.ServerError(c, "Failed to create categories", err)
+		}
+	}
+
+	for i := range categories {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.categories.Create(c.UserContext(), &categories[i]); err != nil {
+				results.serverError(i, "Failed to create category", err)
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusCreated, categories[i].ID, categories[i])
+	}
+
+	return results.send(fiber.StatusCreated, "Categories created successfully")
+}
+
+// BulkUpdateCategories godoc
+// @Summary Update categories in bulk
+// @Description Replace up to 1000 categories, each identified by its id. A non-zero version must be the category's current one, like If-Match. Modes are as for bulk create.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param categories body []models.Category true "Categories to update"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /categories/bulk [put]
+func (h *CategoryHandler) BulkUpdateCategories(c *fiber.Ctx) error {
+	var inputs []models.Category
+	atomic, err := parseBulk(c, &inputs)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(inputs))
+	versions := make([]uint, len(inputs))
+	for i, input := range inputs {
+		ids[i], versions[i] = input.ID, input.Version
+	}
+	stored, err := h.categories.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving categories", err)
+	}
+	byID := make(map[uint]models.Category, len(stored))
+	current := make(map[uint]uint, len(stored))
+	for _, category := range stored {
+		byID[category.ID] = category
+		current[category.ID] = category.Version
+	}
+
+	results := newBulkResults(c, atomic, len(inputs))
+	results.checkTargets(ids, versions, current, "Category not found")
+
+	// categories[i] is the stored category with inputs[i]'s writable fields
+	categories := make([]models.Category, len(inputs))
+	for i, input := range inputs {
+		if results.failed(i) {
+			continue
+		}
+		results.validate(i, validateCategory.Struct(input))
+		categories[i] = byID[input.ID]
+		categories[i].Title = input.Title
+		categories[i].CoverImage = input.CoverImage
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.categories.UpdateMany(c.UserContext(), categories); err != nil {
+			return results.writeFailed(err, "Category not found", "Failed to update categories")
+		}
+	}
+
+	for i := range categories {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.categories.Update(c.UserContext(), &categories[i]); err != nil {
+				if !results.lostRace(i, err, "Category not found") {
+					results.serverError(i, "Failed to update category", err)
+				}
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusOK, categories[i].ID, categories[i])
+	}
+
+	return results.send(fiber.StatusOK, "Categories updated successfully")
+}
+
+// BulkDeleteCategories godoc
+// @Summary Delete categories in bulk
+// @Description Delete up to 1000 categories by id. A non-zero version must be the category's current one, like If-Match. Modes are as for bulk create.
+// @Tags Categories
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param categories body []models.BulkDelete true "Categories to delete"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /categories/bulk [delete]
+func (h *CategoryHandler) BulkDeleteCategories(c *fiber.Ctx) error {
+	var items []models.BulkDelete
+	atomic, err := parseBulk(c, &items)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(items))
+	versions := make([]uint, len(items))
+	for i, item := range items {
+		ids[i], versions[i] = item.ID, item.Version
+	}
+	stored, err := h.categories.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving categories", err)
+	}
+	current := make(map[uint]uint, len(stored))
+	for _, category := range stored {
+		current[category.ID] = category.Version
+	}
+
+	results := newBulkResults(c, atomic, len(items))
+	results.checkTargets(ids, versions, current, "Category not found")
+	return deleteBulk(results, items, h.categories, "Category not found", "Failed to delete category", "Categories deleted successfully")
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorCodec turns cursor payloads into opaque "<payload>.<signature>" tokens
+// and back. The HMAC stops clients from forging boundaries that would let them
+// probe arbitrary column values.
+type cursorCodec struct {
+	secret []byte
+}
+
+func newCursorCodec(secret []byte) cursorCodec {
+	return cursorCodec{secret: secret}
+}
+
+func (cc cursorCodec) encode(v any) string {
+	// Cursor payloads are plain structs; marshalling them cannot fail
+	payload, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(cc.sign(payload))
+}
+
+func (cc cursorCodec) decode(token string, v any) error {
+	rawPayload, rawSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(rawPayload)
+	if err != nil {
+		return errInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(rawSig)
+	if err != nil || !hmac.Equal(sig, cc.sign(payload)) {
+		return errInvalidCursor
+	}
+	if err := json.Unmarshal(payload, v); err != nil {
+		return errInvalidCursor
+	}
+	return nil
+}
+
+func (cc cursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cc.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// productCursor is the signed payload of a product listing cursor. It pins the
+// sort and filter it was issued for, so it cannot be replayed against a
+// different ordering.
+type productCursor struct {
+	Sort   string    `json:"s"`
+	Filter string    `json:"f"`
+	Row    cursorRow `json:"r"`
+}
+
+// cursorRow carries the ID and sort-key values of the boundary product.
+// Fields that are not part of the sort are left empty.
+type cursorRow struct {
+	ID        uint       `json:"id"`
+	Name      string     `json:"name,omitempty"`
+	Price     float64    `json:"price,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// newProductCursor captures product as the boundary of a listing.
+func newProductCursor(product models.Product, sort []repository.SortField, filter repository.ProductFilter) productCursor {
+	keys := repository.NormalizeProductSort(sort)
+	row := cursorRow{ID: product.ID}
+	for _, key := range keys {
+		switch key.Field {
+		case "name":
+			row.Name = product.Name
+		case "price":
+			row.Price = product.Price
+		case "created_at":
+			row.CreatedAt = &product.CreatedAt
+		case "updated_at":
+			row.UpdatedAt = &product.UpdatedAt
+		}
+	}
+	return productCursor{Sort: sortSpec(keys), Filter: filterFingerprint(filter), Row: row}
+}
+
+// boundary verifies the cursor matches the current listing and returns the
+// product it points at.
+func (pc productCursor) boundary(sort []repository.SortField, filter repository.ProductFilter) (models.Product, error) {
+	if pc.Sort != sortSpec(repository.NormalizeProductSort(sort)) || pc.Filter != filterFingerprint(filter) {
+		return models.Product{}, errInvalidCursor
+	}
+
+	product := models.Product{ID: pc.Row.ID, Name: pc.Row.Name, Price: pc.Row.Price}
+	if pc.Row.CreatedAt != nil {
+		product.CreatedAt = *pc.Row.CreatedAt
+	}
+	if pc.Row.UpdatedAt != nil {
+		product.UpdatedAt = *pc.Row.UpdatedAt
+	}
+	return product, nil
+}
+
+// sortSpec renders normalized sort keys canonically, e.g. "price:desc,id:asc".
+func sortSpec(keys []repository.SortField) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		dir := "asc"
+		if key.Desc {
+			dir = "desc"
+		}
+		parts[i] = key.Field + ":" + dir
+	}
+	return strings.Join(parts, ",")
+}
+
+// filterFingerprint is a short stable digest of the filter values.
+func filterFingerprint(f repository.ProductFilter) string {
+	deref := func(v any) string {
+		switch p := v.(type) {
+		case *uint:
+			if p != nil {
+				return fmt.Sprint(*p)
+			}
+		case *float64:
+			if p != nil {
+				return fmt.Sprint(*p)
+			}
+		}
+		return ""
+	}
+
+	canonical := strings.Join([]string{
+		deref(f.CategoryID), deref(f.BrandID), deref(f.MinPrice), deref(f.MaxPrice), f.Name,
+	}, "\x00")
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+// versionETag returns a strong entity tag built from row versions, e.g. "3"
+// or "3-1-2".
+func versionETag(versions ...uint) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = strconv.FormatUint(uint64(v), 10)
+	}
+	return `"` + strings.Join(parts, "-") + `"`
+}
+
+// productETag covers the embedded brand and category too, since renaming
+// either changes the product's representation.
+func productETag(p *models.Product) string {
+	return versionETag(p.Version, p.Brand.Version, p.Category.Version)
+}
+
+func brandETag(b *models.Brand) string {
+	return versionETag(b.Version)
+}
+
+func categoryETag(cat *models.Category) string {
+	return versionETag(cat.Version)
+}
+
+// ifMatch reports whether the request's If-Match header, if any, lists
+// current or "*". Weak tags never match (RFC 9110 strong comparison).
+func ifMatch(c *fiber.Ctx, current string) bool {
+	header := c.Get(fiber.HeaderIfMatch)
+	return header == "" || matchETag(header, current, false)
+}
+
+// matchETag reports whether a comma-separated If-Match / If-None-Match value
+// lists current or "*". weak selects weak comparison, under which W/"x"
+// matches "x".
+func matchETag(header, current string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// preconditionFailed writes the 412 for a failed If-Match, with the current
+// ETag so the client can refetch and retry.
+func preconditionFailed(c *fiber.Ctx, current string) error {
+	c.Set(fiber.HeaderETag, current)
+	return respond.Problem(c, fiber.StatusPreconditionFailed, respond.CodePreconditionFailed,
+		"The resource has changed since it was fetched")
+}
+
+// versionConflict answers a write that lost a race with another one after
+// the If-Match check: 412 if the client sent If-Match, 409 otherwise.
+func versionConflict(c *fiber.Ctx) error {
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		return respond.Problem(c, fiber.StatusPreconditionFailed, respond.CodePreconditionFailed,
+			"The resource has changed since it was fetched")
+	}
+	return respond.Error(c, fiber.StatusConflict, "The resource was modified concurrently, fetch it and retry")
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/exporter"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// exportFormat reads ?format=, csv by default.
+func exportFormat(c *fiber.Ctx) (exporter.Format, bool) {
+	return exporter.ParseFormat(c.Query("format", string(exporter.CSV)))
+}
+
+// badExportFormat writes the problem for a format exportFormat rejected.
+func badExportFormat(c *fiber.Ctx) error {
+	return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery,
+		"format must be {0}, {1} or {2}", string(exporter.CSV), string(exporter.NDJSON), string(exporter.XLSX))
+}
+
+// sendExport streams an export as an attachment named after the resource and
+// the date. run is called once the handler has returned and the headers are
+// sent, so it cannot change the status any more: a failure is logged and the
+// download ends early.
+func sendExport(c *fiber.Ctx, resource string, format exporter.Format, run func(ctx context.Context, w io.Writer) (int, error)) error {
+	// The request context outlives the handler; the fiber.Ctx does not
+	ctx := c.UserContext()
+	filename := fmt.Sprintf("%s-%s.%s", resource, time.Now().UTC().Format("20060102"), format)
+
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Status(fiber.StatusOK).Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		start := time.Now()
+		rows, err := run(ctx, w)
+		if err != nil {
+			slog.ErrorContext(ctx, "Export failed", "resource", resource, "format", format, "rows", rows, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "Export finished", "resource", resource, "format", format, "rows", rows,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000)
+	})
+	return nil
+}
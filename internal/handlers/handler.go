@@ -1,32 +1,22 @@
 package handlers
 
 import (
-	"github.com/go-playground/validator/v10"
-	"log"
-	"os"
-	"path/filepath"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
 )
 
-var validateProduct = validator.New()
+var validateProduct = respond.Validator()
 
-var validateCategory = validator.New()
+var validateCategory = respond.Validator()
 
-var validateBrand = validator.New()
+var validateBrand = respond.Validator()
 
-func SetupLogFile() *os.File {
-	logDir := "logs"
-	logFile := "server.log"
-
-	// Create logs/ directory if not exists
-	if err := os.MkdirAll(logDir, os.ModePerm); err != nil {
-		log.Fatalf("❌ Failed to create log directory: %v", err)
-	}
-
-	// Open or create the log file
-	file, err := os.OpenFile(filepath.Join(logDir, logFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+// parseID reads the ":id" route parameter as an unsigned primary key.
+func parseID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 0)
 	if err != nil {
-		log.Fatalf("❌ Failed to open log file: %v", err)
+		return 0, err
 	}
-
-	return file
+	return uint(id), nil
 }
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/middleware"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// testAPI serves the catalog routes from in-memory repositories.
+type testAPI struct {
+	t          *testing.T
+	app        *fiber.App
+	products   repository.ProductRepository
+	categories *repository.MemoryCategoryRepository
+	brands     *repository.MemoryBrandRepository
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	categories := repository.NewMemoryCategoryRepository()
+	brands := repository.NewMemoryBrandRepository()
+	return newTestAPIWith(t, repository.NewMemoryProductRepository(categories, brands), categories, brands)
+}
+
+// newTestAPIWith is newTestAPI with a product repository of the caller's
+// choosing, such as one wrapping the memory repository to inject failures.
+func newTestAPIWith(t *testing.T, products repository.ProductRepository, categories *repository.MemoryCategoryRepository, brands *repository.MemoryBrandRepository) *testAPI {
+	productHandler := NewProductHandler(products, categories, brands, []byte("test-cursor-secret"))
+	brandHandler := NewBrandHandler(brands)
+
+	app := fiber.New(fiber.Config{ErrorHandler: respond.ErrorHandler(false)})
+	app.Use(middleware.Locale())
+
+	api := app.Group("/api/v1")
+	api.Get("/products", productHandler.GetAllProducts)
+	api.Get("/products/export", productHandler.ExportProducts)
+	api.Get("/products/search", productHandler.SearchProducts)
+	api.Get("/products/:id", productHandler.GetProductByID)
+	api.Post("/products", productHandler.CreateProduct)
+	api.Post("/products/bulk", productHandler.BulkCreateProducts)
+	api.Post("/products/import", productHandler.ImportProducts)
+	api.Put("/products/bulk", productHandler.BulkUpdateProducts)
+	api.Delete("/products/bulk", productHandler.BulkDeleteProducts)
+	api.Put("/products/:id", productHandler.UpdateProduct)
+	api.Patch("/products/:id", productHandler.PatchProduct)
+	api.Delete("/products/:id", productHandler.DeleteProduct)
+	api.Get("/brands", brandHandler.GetAllBrands)
+	api.Get("/brands/:id", brandHandler.GetBrandByID)
+	api.Post("/brands", brandHandler.CreateBrand)
+	api.Put("/brands/:id", brandHandler.UpdateBrand)
+	api.Patch("/brands/:id", brandHandler.PatchBrand)
+	api.Delete("/brands/:id", brandHandler.DeleteBrand)
+
+	return &testAPI{t: t, app: app, products: products, categories: categories, brands: brands}
+}
+
+// seedReferences creates a category and a brand for products to point at.
+func (api *testAPI) seedReferences() (models.Category, models.Brand) {
+	api.t.Helper()
+	ctx := context.Background()
+
+	category := models.Category{Title: "Phones", CoverImage: "https://example.com/phones.png"}
+	if err := api.categories.Create(ctx, &category); err != nil {
+		api.t.Fatal(err)
+	}
+	brand := models.Brand{Name: "Acme", CoverImage: "https://example.com/acme.png"}
+	if err := api.brands.Create(ctx, &brand); err != nil {
+		api.t.Fatal(err)
+	}
+	return category, brand
+}
+
+// do sends a request with body encoded as JSON (a string or []byte is sent
+// as is) and headers given as name, value pairs.
+func (api *testAPI) do(method, path string, body any, headers ...string) (*http.Response, []byte) {
+	api.t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = bytes.NewReader([]byte(body))
+	case []byte:
+		reader = bytes.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			api.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := api.app.Test(req, -1)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		api.t.Fatal(err)
+	}
+	return resp, data
+}
+
+// expect fails the test unless resp has status.
+func (api *testAPI) expect(resp *http.Response, body []byte, status int) {
+	api.t.Helper()
+	if resp.StatusCode != status {
+		api.t.Fatalf("%s %s: status %d, want %d; body: %s",
+			resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, status, body)
+	}
+}
+
+// decodeData decodes the data member of an APIResponse into T.
+func decodeData[T any](t *testing.T, body []byte) T {
+	t.Helper()
+	var envelope struct {
+		Data T `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return envelope.Data
+}
+
+func decodeProblem(t *testing.T, body []byte) models.Problem {
+	t.Helper()
+	var problem models.Problem
+	if err := json.Unmarshal(body, &problem); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return problem
+}
+
+// fieldRules maps each invalid field of a problem to its rule.
+func fieldRules(problem models.Problem) map[string]string {
+	rules := make(map[string]string, len(problem.Errors))
+	for _, field := range problem.Errors {
+		rules[field.Field] = field.Rule
+	}
+	return rules
+}
+
+// decodeMeta returns the pagination meta of an APIResponse.
+func decodeMeta(t *testing.T, body []byte) models.PaginationMeta {
+	t.Helper()
+	var response struct {
+		Meta models.PaginationMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return response.Meta
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"encoding/json"
+	"errors"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gofiber/fiber/v2"
+	"reflect"
+	"strings"
+)
+
+// Patch formats accepted by the PATCH routes.
+const (
+	MIMEMergePatch = "application/merge-patch+json" // RFC 7386
+	MIMEJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// acceptPatch is the Accept-Patch header sent when the format is rejected.
+const acceptPatch = MIMEMergePatch + ", " + MIMEJSONPatch
+
+// patchError is a patch that could not be applied. key is an English
+// translation key whose placeholders are filled from params.
+type patchError struct {
+	status int
+	code   string
+	key    string
+	params []string
+}
+
+func (e *patchError) Error() string {
+	return i18n.Format(e.key, e.params...)
+}
+
+// applyPatch applies the request body to the JSON form of current and decodes
+// the resulting document into patched. The patch format is chosen by the
+// Content-Type header. current is left untouched, and nothing is validated:
+// callers validate patched exactly as they would a PUT body.
+func applyPatch(c *fiber.Ctx, current, patched any) error {
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+
+	var result []byte
+	switch mediaType(c.Get(fiber.HeaderContentType)) {
+	case MIMEMergePatch:
+		result, err = jsonpatch.MergePatch(doc, c.Body())
+	case MIMEJSONPatch:
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(c.Body()); err == nil {
+			result, err = patch.Apply(doc)
+		}
+	default:
+		c.Set("Accept-Patch", acceptPatch)
+		return &patchError{
+			status: fiber.StatusUnsupportedMediaType,
+			code:   respond.CodeUnsupportedMediaType,
+			key:    "Content-Type must be {0} or {1}",
+			params: []string{MIMEMergePatch, MIMEJSONPatch},
+		}
+	}
+
+	switch {
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return &patchError{status: fiber.StatusConflict, code: respond.CodeConflict, key: "A test operation in the patch failed"}
+	case errors.Is(err, jsonpatch.ErrMissing), errors.Is(err, jsonpatch.ErrInvalidIndex):
+		return &patchError{status: fiber.StatusUnprocessableEntity, code: respond.CodePatchFailed, key: "The patch does not apply to this resource"}
+	case err != nil:
+		return &patchError{status: fiber.StatusBadRequest, code: respond.CodeInvalidBody, key: "Invalid patch document"}
+	}
+
+	return json.Unmarshal(result, patched)
+}
+
+// patchFailed writes the problem for an error returned by applyPatch. A field
+// patched to the wrong JSON type is reported like any other invalid field.
+func patchFailed(c *fiber.Ctx, err error) error {
+	var patchErr *patchError
+	if errors.As(err, &patchErr) {
+		return respond.Problem(c, patchErr.status, patchErr.code, patchErr.key, patchErr.params...)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return respond.Invalid(c, models.FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   jsonType(typeErr.Type),
+			Message: "Must be a {0}",
+		})
+	}
+	return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid patch document")
+}
+
+// mediaType strips parameters such as charset from a Content-Type value.
+func mediaType(contentType string) string {
+	mime, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(mime))
+}
+
+// jsonType names the JSON type a Go type is decoded from.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
@@ -1,60 +1,303 @@
 package handlers
 
 import (
-	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/exporter"
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/importer"
+	"Scalable-Secure-Go-Web/internal/middleware"
 	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
-	"gorm.io/gorm"
+	"io"
 	"strconv"
+	"time"
+	"unicode/utf8"
 )
 
+// ProductHandler serves the /products routes. Category and brand repositories
+// are needed to validate the foreign keys of incoming products.
+type ProductHandler struct {
+	products   repository.ProductRepository
+	categories repository.CategoryRepository
+	brands     repository.BrandRepository
+	cursors    cursorCodec
+	importer   *importer.Importer
+}
+
+// NewProductHandler returns a ProductHandler backed by the given repositories.
+// cursorSecret signs the keyset pagination cursors handed out by GetAllProducts.
+func NewProductHandler(products repository.ProductRepository, categories repository.CategoryRepository, brands repository.BrandRepository, cursorSecret []byte) *ProductHandler {
+	return &ProductHandler{
+		products:   products,
+		categories: categories,
+		brands:     brands,
+		cursors:    newCursorCodec(cursorSecret),
+		importer:   importer.New(products, categories, brands),
+	}
+}
+
 // GetAllProducts godoc
 // @Summary Get all products with pagination
-// @Description Retrieve a list of products with pagination and relations
+// @Description Retrieve a list of products with pagination, filtering, sorting and relations.
+// @Description Pass a next_cursor/prev_cursor from a previous response as after/before to switch to keyset pagination.
 // @Tags Products
 // @Accept json
 // @Produce json
 // @Param page query int false "Page number"
-// @Param limit query int false "Items per page"
+// @Param limit query int false "Items per page (max 100)"
+// @Param category_id query int false "Only products in this category"
+// @Param brand_id query int false "Only products from this brand"
+// @Param min_price query number false "Minimum price (inclusive)"
+// @Param max_price query number false "Maximum price (inclusive)"
+// @Param name query string false "Case-insensitive substring of the product name"
+// @Param sort query string false "Comma-separated sort keys (id, name, price, created_at, updated_at) with optional :asc/:desc, e.g. price:desc,name"
+// @Param after query string false "Cursor: return products after this position (replaces page)"
+// @Param before query string false "Cursor: return products before this position (replaces page)"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "When any product, category or brand last changed, deletions included"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
 // @Router /products [get]
-func GetAllProducts(c *fiber.Ctx) error {
+func (h *ProductHandler) GetAllProducts(c *fiber.Ctx) error {
 	// Parse query parameters
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-	if page < 1 {
-		page = 1
+	pager := parsePagination(c)
+
+	// Parse and validate filters
+	filter, sort, err := parseProductListing(c)
+	if err != nil {
+		return listingRejected(c, err)
 	}
-	if limit < 1 {
-		limit = 10
+
+	// Keyset pagination takes over when a cursor is supplied
+	if c.Query("after") != "" || c.Query("before") != "" {
+		return h.listProductsByCursor(c, pager.Limit, filter, sort)
 	}
-	offset := (page - 1) * limit
 
-	var products []models.Product
+	// Read before the page, so a concurrent write can only make it look older
+	modified, err := h.catalogModified(c.UserContext())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch products", err)
+	}
 
-	// Query products with related Category and Brand using GORM Preload
-	err := config.DB.
-		Preload("Category").
-		Preload("Brand").
-		Limit(limit).
-		Offset(offset).
-		Find(&products).Error
+	// Query products with related Category and Brand
+	products, total, err := h.products.List(c.UserContext(), repository.ProductQuery{
+		Page:   pager.window(),
+		Filter: filter,
+		Sort:   sort,
+	})
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch products", err)
+	}
 
+	// Hand out cursors so clients can move on to keyset pagination
+	meta := pager.meta(c, total)
+	if len(products) > 0 {
+		if meta.Next != "" {
+			meta.NextCursor = h.cursors.encode(newProductCursor(products[len(products)-1], sort, filter))
+		}
+		if pager.Page > 1 {
+			meta.PrevCursor = h.cursors.encode(newProductCursor(products[0], sort, filter))
+		}
+	}
+
+	return sendCacheable(c, models.APIResponse{
+		Status:     "success",
+		StatusCode: 200,
+		Data:       products,
+		Message:    "Products fetched successfully",
+		Meta:       meta,
+	}, modified)
+}
+
+// listProductsByCursor serves GetAllProducts in keyset mode (after= or before=).
+func (h *ProductHandler) listProductsByCursor(c *fiber.Ctx, limit int, filter repository.ProductFilter, sort []repository.SortField) error {
+	after, before := c.Query("after"), c.Query("before")
+	if after != "" && before != "" {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery, "Use either after or before, not both")
+	}
+
+	token, backward := after, false
+	if before != "" {
+		token, backward = before, true
+	}
+
+	var cursor productCursor
+	var boundary models.Product
+	err := h.cursors.decode(token, &cursor)
+	if err == nil {
+		boundary, err = cursor.boundary(sort, filter)
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to fetch products",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery, "Invalid cursor")
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.APIResponse{
+	// Read before the page, so a concurrent write can only make it look older
+	modified, err := h.catalogModified(c.UserContext())
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch products", err)
+	}
+
+	// Fetch one extra row to learn whether another page exists in this direction
+	products, err := h.products.ListKeyset(c.UserContext(), repository.ProductQuery{
+		Page:   repository.Page{Limit: limit + 1},
+		Filter: filter,
+		Sort:   sort,
+	}, &repository.Keyset{Boundary: boundary, Backward: backward})
+	if err != nil {
+		return respond.ServerError(c, "Failed to fetch products", err)
+	}
+
+	hasMore := len(products) > limit
+	if hasMore {
+		if backward {
+			products = products[1:]
+		} else {
+			products = products[:limit]
+		}
+	}
+
+	meta := &models.PaginationMeta{Limit: limit}
+	if len(products) > 0 {
+		// The side we came from always has rows; the side we moved towards only if hasMore
+		if !backward || hasMore {
+			meta.PrevCursor = h.cursors.encode(newProductCursor(products[0], sort, filter))
+			meta.Prev = cursorLink(c, "before", meta.PrevCursor, limit)
+		}
+		if backward || hasMore {
+			meta.NextCursor = h.cursors.encode(newProductCursor(products[len(products)-1], sort, filter))
+			meta.Next = cursorLink(c, "after", meta.NextCursor, limit)
+		}
+	}
+
+	return sendCacheable(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       products,
 		Message:    "Products fetched successfully",
+		Meta:       meta,
+	}, modified)
+}
+
+// maxSearchLength caps the q parameter of SearchProducts, in characters.
+const maxSearchLength = 200
+
+// SearchProducts godoc
+// @Summary Full-text search over products
+// @Description Find the products whose name or description contains every word of q, most relevant first. Words match as prefixes ("head" finds "headphones") and punctuation only separates words. Matches in the name rank above matches in the description; scores are only comparable within one result set. Each hit carries HTML highlights of its name and of a description excerpt, every match wrapped in <mark> and the rest escaped. Takes the filters of GET /products, but not sort.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param q query string true "Words to search for (at most 10 words, 200 characters)"
+// @Param page query int false "Page number"
+// @Param limit query int false "Items per page (max 100)"
+// @Param category_id query int false "Only products in this category"
+// @Param brand_id query int false "Only products from this brand"
+// @Param min_price query number false "Minimum price (inclusive)"
+// @Param max_price query number false "Maximum price (inclusive)"
+// @Param name query string false "Case-insensitive substring of the product name"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
+// @Success 200 {object} models.APIResponse{data=[]models.SearchHit}
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "When any product, category or brand last changed, deletions included"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Router /products/search [get]
+func (h *ProductHandler) SearchProducts(c *fiber.Ctx) error {
+	pager := parsePagination(c)
+
+	q := c.Query("q")
+	terms := repository.SearchTerms(q)
+	switch {
+	case utf8.RuneCountInString(q) > maxSearchLength:
+		return respond.Invalid(c, models.FieldError{
+			Field: "q", Rule: "max", Param: strconv.Itoa(maxSearchLength), Message: "q must be at most {0} characters long",
+		})
+	case len(terms) == 0:
+		return respond.Invalid(c, models.FieldError{Field: "q", Rule: "required", Message: "q must contain a letter or a digit"})
+	case len(terms) > repository.MaxSearchTerms:
+		return respond.Invalid(c, models.FieldError{
+			Field: "q", Rule: "max_words", Param: strconv.Itoa(repository.MaxSearchTerms), Message: "q must not have more than {0} words",
+		})
+	}
+
+	filter, sort, err := parseProductListing(c)
+	if err != nil {
+		return listingRejected(c, err)
+	}
+	if sort != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery, "Search results are ranked by relevance and cannot be sorted")
+	}
+
+	// Read before the page, so a concurrent write can only make it look older
+	modified, err := h.catalogModified(c.UserContext())
+	if err != nil {
+		return respond.ServerError(c, "Failed to search products", err)
+	}
+
+	hits, total, err := h.products.Search(c.UserContext(), repository.SearchQuery{
+		Page:   pager.window(),
+		Terms:  terms,
+		Filter: filter,
+	})
+	if err != nil {
+		return respond.ServerError(c, "Failed to search products", err)
+	}
+
+	return sendCacheable(c, models.APIResponse{
+		Status:     "success",
+		StatusCode: 200,
+		Data:       hits,
+		Message:    "Products found successfully",
+		Meta:       pager.meta(c, total),
+	}, modified)
+}
+
+// ExportProducts godoc
+// @Summary Export products as CSV, NDJSON or XLSX
+// @Description Stream every product matching the filters, with the title of its category and the name of its brand, as a file download. Takes the filters and sort of GET /products; the whole result is sent, without pagination. Columns: id, name, description, price, cover_image, category_id, category, brand_id, brand, version, created_at, updated_at. In CSV, text a spreadsheet would run as a formula (starting with =, +, -, @) is prefixed with a quote.
+// @Tags Products
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "csv (default), ndjson or xlsx"
+// @Param category_id query int false "Only products in this category"
+// @Param brand_id query int false "Only products from this brand"
+// @Param min_price query number false "Minimum price (inclusive)"
+// @Param max_price query number false "Maximum price (inclusive)"
+// @Param name query string false "Case-insensitive substring of the product name"
+// @Param sort query string false "Comma-separated sort keys (id, name, price, created_at, updated_at) with optional :asc/:desc, e.g. price:desc,name"
+// @Success 200 {file} file
+// @Header 200 {string} Content-Disposition "Attachment named after the resource and date, e.g. products-20250709.csv"
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 429 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/export [get]
+func (h *ProductHandler) ExportProducts(c *fiber.Ctx) error {
+	format, ok := exportFormat(c)
+	if !ok {
+		return badExportFormat(c)
+	}
+	filter, sort, err := parseProductListing(c)
+	if err != nil {
+		return listingRejected(c, err)
+	}
+
+	return sendExport(c, "products", format, func(ctx context.Context, w io.Writer) (int, error) {
+		return exporter.Products(ctx, w, h.products, exporter.Options{Format: format, Filter: filter, Sort: sort})
 	})
 }
 
@@ -65,38 +308,37 @@ func GetAllProducts(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-None-Match header string false "ETag of the copy the client holds"
+// @Param If-Modified-Since header string false "Last-Modified of the copy the client holds"
 // @Success 200 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Success 304 "Not modified"
+// @Header 200,304 {string} ETag "Strong entity tag"
+// @Header 200,304 {string} Last-Modified "Most recent updated_at in the response"
+// @Header 200,304 {string} Cache-Control "Route group caching policy"
+// @Failure 400 {object} models.Problem
+// @Failure 404 {object} models.Problem
 // @Router /products/{id} [get]
-func GetProductByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	var product models.Product
+func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid product ID")
+	}
 
-	// Fetch product and preload Category and Brand
-	if err := config.DB.Preload("Category").Preload("Brand").First(&product, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-				Status:     "error",
-				StatusCode: 404,
-				Data:       nil,
-				Message:    "Product not found",
-			})
+	// Fetch product with its Category and Brand
+	product, err := h.products.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Product not found")
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Error retrieving product",
-		})
+		return respond.ServerError(c, "Error retrieving product", err)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(models.APIResponse{
+	return sendVersioned(c, models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
 		Data:       product,
 		Message:    "Product fetched successfully",
-	})
+	}, productETag(product), productModified(product))
 }
 
 // CreateProduct godoc
@@ -106,59 +348,38 @@ func GetProductByID(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param product body models.Product true "Product JSON"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
 // @Success 201 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 500 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /products [post]
-func CreateProduct(c *fiber.Ctx) error {
+func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	var product models.Product
 
 	// Parse JSON input
 	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid request body",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
 	}
 
 	// Validate input using validator package
 	if err := validateProduct.Struct(product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	// Validate foreign keys: CategoryID and BrandID must exist
-	if err := config.DB.First(&models.Category{}, product.CategoryID).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid CategoryID",
-		})
-	}
-	if err := config.DB.First(&models.Brand{}, product.BrandID).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid BrandID",
-		})
+	if fields := h.checkReferences(c, product); len(fields) > 0 {
+		return respond.Invalid(c, fields...)
 	}
 
 	// Create product
-	if err := config.DB.Create(&product).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to create product",
-		})
+	if err := h.products.Create(c.UserContext(), &product); err != nil {
+		return respond.ServerError(c, "Failed to create product", err)
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
@@ -176,61 +397,106 @@ func CreateProduct(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Param product body models.Product true "Product JSON"
 // @Success 200 {object} models.APIResponse
-// @Failure 400 {object} models.APIResponse
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /products/{id} [put]
-func UpdateProduct(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var existing models.Product
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid product ID")
+	}
 
 	// Fetch the product
-	if err := config.DB.First(&existing, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Product not found",
-		})
+	existing, err := h.products.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Product not found")
+		}
+		return respond.ServerError(c, "Error retrieving product", err)
+	}
+
+	if current := productETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
 	var input models.Product
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid input",
-		})
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, "Invalid request body")
+	}
+
+	return h.update(c, existing, input)
+}
+
+// PatchProduct godoc
+// @Summary Partially update a product
+// @Description Apply a JSON Merge Patch (application/merge-patch+json) or JSON Patch (application/json-patch+json) to a product. Only the patched document is validated.
+// @Tags Products
+// @Accept application/merge-patch+json
+// @Accept application/json-patch+json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
+// @Param patch body object true "Merge patch object or JSON Patch operations"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/{id} [patch]
+func (h *ProductHandler) PatchProduct(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid product ID")
+	}
+
+	existing, err := h.products.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Product not found")
+		}
+		return respond.ServerError(c, "Error retrieving product", err)
+	}
+
+	if current := productETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
+	var input models.Product
+	if err := applyPatch(c, existing, &input); err != nil {
+		return patchFailed(c, err)
+	}
+
+	return h.update(c, existing, input)
+}
+
+// update validates input, copies its writable fields onto existing and saves
+// it. It backs both PUT and PATCH.
+func (h *ProductHandler) update(c *fiber.Ctx, existing *models.Product, input models.Product) error {
 	// Validate input
 	if err := validateProduct.Struct(input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    err.Error(),
-		})
+		return respond.ValidationFailed(c, err)
 	}
 
 	// Check if referenced Category and Brand exist
-	if err := config.DB.First(&models.Category{}, input.CategoryID).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid CategoryID",
-		})
-	}
-	if err := config.DB.First(&models.Brand{}, input.BrandID).Error; err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 400,
-			Data:       nil,
-			Message:    "Invalid BrandID",
-		})
+	if fields := h.checkReferences(c, input); len(fields) > 0 {
+		return respond.Invalid(c, fields...)
 	}
 
 	// Update fields
@@ -241,19 +507,24 @@ func UpdateProduct(c *fiber.Ctx) error {
 	existing.CategoryID = input.CategoryID
 	existing.BrandID = input.BrandID
 
-	if err := config.DB.Save(&existing).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to update product",
-		})
+	if err := h.products.Update(c.UserContext(), existing); err != nil {
+		if errors.Is(err, repository.ErrVersionConflict) {
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to update product", err)
+	}
+
+	// Reload so the response carries the (possibly changed) Category and Brand
+	updated, err := h.products.FindByID(c.UserContext(), existing.ID)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving product", err)
 	}
 
+	c.Set(fiber.HeaderETag, productETag(updated))
 	return c.Status(fiber.StatusOK).JSON(models.APIResponse{
 		Status:     "success",
 		StatusCode: 200,
-		Data:       existing,
+		Data:       updated,
 		Message:    "Product updated successfully",
 	})
 }
@@ -265,32 +536,402 @@ func UpdateProduct(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from a previous GET; the write fails with 412 if the resource has changed"
 // @Success 204 {object} nil
-// @Failure 404 {object} models.APIResponse
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 428 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /products/{id} [delete]
-func DeleteProduct(c *fiber.Ctx) error {
-	id := c.Params("id")
-	var product models.Product
+func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
+	id, err := parseID(c)
+	if err != nil {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidID, "Invalid product ID")
+	}
 
-	// Check if the product exists
-	if err := config.DB.First(&product, id).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 404,
-			Data:       nil,
-			Message:    "Product not found",
-		})
+	existing, err := h.products.FindByID(c.UserContext(), id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return respond.Error(c, fiber.StatusNotFound, "Product not found")
+		}
+		return respond.ServerError(c, "Error retrieving product", err)
+	}
+
+	if current := productETag(existing); !ifMatch(c, current) {
+		return preconditionFailed(c, current)
 	}
 
 	// Delete product
-	if err := config.DB.Delete(&product).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-			Status:     "error",
-			StatusCode: 500,
-			Data:       nil,
-			Message:    "Failed to delete product",
-		})
+	if err := h.products.Delete(c.UserContext(), id, existing.Version); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return respond.Error(c, fiber.StatusNotFound, "Product not found")
+		case errors.Is(err, repository.ErrVersionConflict):
+			return versionConflict(c)
+		}
+		return respond.ServerError(c, "Failed to delete product", err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// BulkCreateProducts godoc
+// @Summary Create products in bulk
+// @Description Create up to 1000 products from a JSON array, checking every category_id and brand_id with one query per table. In atomic mode (the default) nothing is created unless every item is valid, and the problem lists each failed item's errors under "[index]". In partial mode the valid items are created and each item's outcome is reported, with 207 if any failed.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param products body []models.Product true "Products to create"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response"
+// @Success 201 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/bulk [post]
+func (h *ProductHandler) BulkCreateProducts(c *fiber.Ctx) error {
+	var products []models.Product
+	atomic, err := parseBulk(c, &products)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	results := newBulkResults(c, atomic, len(products))
+	for i := range products {
+		results.validate(i, validateProduct.Struct(products[i]))
+	}
+	if err := h.checkBulkReferences(c, results, products); err != nil {
+		return respond.ServerError(c, "Error checking product references", err)
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.products.CreateMany(c.UserContext(), products); err != nil {
+			return respond.ServerError(c, "Failed to create products", err)
+		}
+	}
+
+	for i := range products {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.products.Create(c.UserContext(), &products[i]); err != nil {
+				results.serverError(i, "Failed to create product", err)
+				continue
+			}
+		}
+		results.ok(i, fiber.StatusCreated, products[i].ID, products[i])
+	}
+
+	return results.send(fiber.StatusCreated, "Products created successfully")
+}
+
+// BulkUpdateProducts godoc
+// @Summary Update products in bulk
+// @Description Replace up to 1000 products, each identified by its id. A non-zero version must be the product's current one, like If-Match. Modes are as for bulk create.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param products body []models.Product true "Products to update"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/bulk [put]
+func (h *ProductHandler) BulkUpdateProducts(c *fiber.Ctx) error {
+	var inputs []models.Product
+	atomic, err := parseBulk(c, &inputs)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(inputs))
+	versions := make([]uint, len(inputs))
+	for i, input := range inputs {
+		ids[i], versions[i] = input.ID, input.Version
+	}
+	stored, err := h.products.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving products", err)
+	}
+	byID := make(map[uint]models.Product, len(stored))
+	current := make(map[uint]uint, len(stored))
+	for _, product := range stored {
+		byID[product.ID] = product
+		current[product.ID] = product.Version
+	}
+
+	results := newBulkResults(c, atomic, len(inputs))
+	results.checkTargets(ids, versions, current, "Product not found")
+	for i, input := range inputs {
+		if !results.failed(i) {
+			results.validate(i, validateProduct.Struct(input))
+		}
+	}
+	if err := h.checkBulkReferences(c, results, inputs); err != nil {
+		return respond.ServerError(c, "Error checking product references", err)
+	}
+
+	// products[i] is the stored product with inputs[i]'s writable fields
+	products := make([]models.Product, len(inputs))
+	for i, input := range inputs {
+		if results.failed(i) {
+			continue
+		}
+		products[i] = byID[input.ID]
+		products[i].Name = input.Name
+		products[i].Description = input.Description
+		products[i].Price = input.Price
+		products[i].CoverImage = input.CoverImage
+		products[i].CategoryID = input.CategoryID
+		products[i].BrandID = input.BrandID
+	}
+
+	if atomic {
+		if results.anyFailed() {
+			return results.reject()
+		}
+		if err := h.products.UpdateMany(c.UserContext(), products); err != nil {
+			return results.writeFailed(err, "Product not found", "Failed to update products")
+		}
+	}
+
+	var updatedIDs []uint
+	for i := range products {
+		if results.failed(i) {
+			continue
+		}
+		if !atomic {
+			if err := h.products.Update(c.UserContext(), &products[i]); err != nil {
+				if !results.lostRace(i, err, "Product not found") {
+					results.serverError(i, "Failed to update product", err)
+				}
+				continue
+			}
+		}
+		updatedIDs = append(updatedIDs, products[i].ID)
+	}
+
+	// Reload so the results carry the (possibly changed) Category and Brand
+	updated, err := h.products.FindByIDs(c.UserContext(), updatedIDs)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving products", err)
+	}
+	reloaded := make(map[uint]models.Product, len(updated))
+	for _, product := range updated {
+		reloaded[product.ID] = product
+	}
+	for i := range products {
+		if !results.failed(i) {
+			results.ok(i, fiber.StatusOK, products[i].ID, reloaded[products[i].ID])
+		}
+	}
+
+	return results.send(fiber.StatusOK, "Products updated successfully")
+}
+
+// BulkDeleteProducts godoc
+// @Summary Delete products in bulk
+// @Description Delete up to 1000 products by id. A non-zero version must be the product's current one, like If-Match. Modes are as for bulk create.
+// @Tags Products
+// @Accept json
+// @Produce json
+// @Param mode query string false "atomic (default) or partial" Enums(atomic, partial)
+// @Param products body []models.BulkDelete true "Products to delete"
+// @Success 200 {object} models.APIResponse{data=models.BulkResult}
+// @Success 207 {object} models.APIResponse{data=models.BulkResult}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 404 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 412 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/bulk [delete]
+func (h *ProductHandler) BulkDeleteProducts(c *fiber.Ctx) error {
+	var items []models.BulkDelete
+	atomic, err := parseBulk(c, &items)
+	if err != nil {
+		return bulkRejected(c, err)
+	}
+
+	ids := make([]uint, len(items))
+	versions := make([]uint, len(items))
+	for i, item := range items {
+		ids[i], versions[i] = item.ID, item.Version
+	}
+	stored, err := h.products.FindByIDs(c.UserContext(), ids)
+	if err != nil {
+		return respond.ServerError(c, "Error retrieving products", err)
+	}
+	current := make(map[uint]uint, len(stored))
+	for _, product := range stored {
+		current[product.ID] = product.Version
+	}
+
+	results := newBulkResults(c, atomic, len(items))
+	results.checkTargets(ids, versions, current, "Product not found")
+	return deleteBulk(results, items, h.products, "Product not found", "Failed to delete product", "Products deleted successfully")
+}
+
+// ImportProducts godoc
+// @Summary Import products from a CSV or NDJSON feed
+// @Description Create a product for each valid row of a feed, naming each row's category and brand instead of giving IDs. CSV needs a header row with the columns name, description, price, cover_image, category and brand (category_cover_image and brand_cover_image are optional); NDJSON has one object per line with the same keys. Invalid rows are skipped and listed by line in the report, with 207 if there are any. With create_missing=true, categories and brands that no record matches are created, using the row's category_cover_image or brand_cover_image.
+// @Tags Products
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param create_missing query bool false "Create missing categories and brands (needs categories:write and brands:write)"
+// @Param feed body string true "CSV with a header row, or one JSON object per line"
+// @Param Idempotency-Key header string false "Client-chosen key; retries with the same key and body replay the first response. With a key the feed is buffered and limited to BODY_LIMIT"
+// @Success 200 {object} models.APIResponse{data=models.ImportReport}
+// @Success 207 {object} models.APIResponse{data=models.ImportReport}
+// @Failure 400 {object} models.Problem
+// @Failure 401 {object} models.Problem
+// @Failure 403 {object} models.Problem
+// @Failure 409 {object} models.Problem
+// @Failure 413 {object} models.Problem
+// @Failure 415 {object} models.Problem
+// @Failure 422 {object} models.Problem
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /products/import [post]
+func (h *ProductHandler) ImportProducts(c *fiber.Ctx) error {
+	format, ok := importer.FormatFor(mediaType(c.Get(fiber.HeaderContentType)))
+	if !ok {
+		return respond.Problem(c, fiber.StatusUnsupportedMediaType, respond.CodeUnsupportedMediaType,
+			"Content-Type must be {0} or {1}", importer.MIMECSV, importer.MIMENDJSON)
+	}
+
+	// The feed streams in as it is imported; see middleware.StreamBody
+	report, err := h.importer.Import(c.UserContext(), middleware.BodyStream(c), importer.Options{
+		Format:        format,
+		CreateMissing: c.QueryBool("create_missing"),
+		Translator:    i18n.FromContext(c.UserContext()),
+	})
+	var feedErr *importer.FeedError
+	switch {
+	case errors.Is(err, middleware.ErrBodyTooLarge):
+		return respond.Error(c, fiber.StatusRequestEntityTooLarge, "Request Entity Too Large")
+	case errors.As(err, &feedErr):
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidBody, feedErr.Key, feedErr.Params...)
+	case err != nil:
+		return respond.ServerError(c, "Failed to import products", err)
+	}
+
+	status, message := fiber.StatusOK, "Products imported successfully"
+	if report.Failed > 0 {
+		status, message = fiber.StatusMultiStatus, i18n.T(i18n.FromContext(c.UserContext()), "{0} of {1} rows failed",
+			strconv.Itoa(report.Failed), strconv.Itoa(report.Rows))
+	}
+	return c.Status(status).JSON(models.APIResponse{
+		Status:     "success",
+		StatusCode: status,
+		Data:       report,
+		Message:    message,
+	})
+}
+
+// checkReferences reports category_id and brand_id values that do not match
+// an existing row.
+func (h *ProductHandler) checkReferences(c *fiber.Ctx, product models.Product) []models.FieldError {
+	_, categoryErr := h.categories.FindByID(c.UserContext(), product.CategoryID)
+	_, brandErr := h.brands.FindByID(c.UserContext(), product.BrandID)
+	return referenceErrors(categoryErr == nil, brandErr == nil)
+}
+
+// checkBulkReferences is checkReferences for the products of a bulk request
+// that have not failed yet, with one query per referenced table.
+func (h *ProductHandler) checkBulkReferences(c *fiber.Ctx, results *bulkResults, products []models.Product) error {
+	var categoryIDs, brandIDs []uint
+	for i, product := range products {
+		if !results.failed(i) {
+			categoryIDs = append(categoryIDs, product.CategoryID)
+			brandIDs = append(brandIDs, product.BrandID)
+		}
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	categories, err := h.categories.FindByIDs(c.UserContext(), categoryIDs)
+	if err != nil {
+		return err
+	}
+	brands, err := h.brands.FindByIDs(c.UserContext(), brandIDs)
+	if err != nil {
+		return err
+	}
+	categoryExists := make(map[uint]bool, len(categories))
+	for _, category := range categories {
+		categoryExists[category.ID] = true
+	}
+	brandExists := make(map[uint]bool, len(brands))
+	for _, brand := range brands {
+		brandExists[brand.ID] = true
+	}
+
+	for i, product := range products {
+		if results.failed(i) {
+			continue
+		}
+		if fields := referenceErrors(categoryExists[product.CategoryID], brandExists[product.BrandID]); len(fields) > 0 {
+			results.invalid(i, fields...)
+		}
+	}
+	return nil
+}
+
+// referenceErrors reports a product's category_id and brand_id if they do not
+// match an existing record.
+func referenceErrors(categoryExists, brandExists bool) []models.FieldError {
+	var fields []models.FieldError
+	if !categoryExists {
+		fields = append(fields, models.FieldError{Field: "category_id", Rule: "exists", Message: "category_id does not match an existing category"})
+	}
+	if !brandExists {
+		fields = append(fields, models.FieldError{Field: "brand_id", Rule: "exists", Message: "brand_id does not match an existing brand"})
+	}
+	return fields
+}
+
+// catalogModified is when any product, category or brand last changed. It
+// dates every product list and search, as products embed their category and
+// brand.
+func (h *ProductHandler) catalogModified(ctx context.Context) (time.Time, error) {
+	var modified time.Time
+	for _, table := range []interface {
+		Modified(ctx context.Context) (time.Time, error)
+	}{h.products, h.categories, h.brands} {
+		t, err := table.Modified(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		modified = latest(modified, t)
+	}
+	return modified, nil
+}
@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func validProduct(category models.Category, brand models.Brand) fiber.Map {
+	return fiber.Map{
+		"name":        "Phone X",
+		"description": "A phone",
+		"price":       499.5,
+		"cover_image": "https://example.com/phone-x.png",
+		"category_id": category.ID,
+		"brand_id":    brand.ID,
+	}
+}
+
+func TestProductCRUD(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+
+	resp, body := api.do(http.MethodPost, "/api/v1/products", validProduct(category, brand))
+	api.expect(resp, body, fiber.StatusCreated)
+	created := decodeData[models.Product](t, body)
+	if created.ID == 0 || created.Version != 1 {
+		t.Fatalf("created product has id %d, version %d", created.ID, created.Version)
+	}
+	path := fmt.Sprintf("/api/v1/products/%d", created.ID)
+
+	resp, body = api.do(http.MethodGet, path, nil)
+	api.expect(resp, body, fiber.StatusOK)
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if etag != `"1-1-1"` {
+		t.Fatalf("ETag = %s, want \"1-1-1\"", etag)
+	}
+	if resp.Header.Get(fiber.HeaderLastModified) == "" {
+		t.Fatal("single product has no Last-Modified")
+	}
+	if got := decodeData[models.Product](t, body); got.Brand.Name != "Acme" || got.Category.Title != "Phones" {
+		t.Fatalf("brand %q and category %q are not embedded", got.Brand.Name, got.Category.Title)
+	}
+
+	resp, body = api.do(http.MethodGet, path, nil, fiber.HeaderIfNoneMatch, etag)
+	api.expect(resp, body, fiber.StatusNotModified)
+
+	update := validProduct(category, brand)
+	update["price"] = 399.0
+	resp, body = api.do(http.MethodPut, path, update, fiber.HeaderIfMatch, etag)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[models.Product](t, body); got.Price != 399 || got.Version != 2 || got.Brand.Name != "Acme" {
+		t.Fatalf("updated product has price %v, version %d, brand %q", got.Price, got.Version, got.Brand.Name)
+	}
+	if newTag := resp.Header.Get(fiber.HeaderETag); newTag != `"2-1-1"` {
+		t.Fatalf("ETag after update = %s, want \"2-1-1\"", newTag)
+	}
+
+	// The old tag no longer matches
+	resp, body = api.do(http.MethodPut, path, update, fiber.HeaderIfMatch, etag)
+	api.expect(resp, body, fiber.StatusPreconditionFailed)
+	resp, body = api.do(http.MethodDelete, path, nil, fiber.HeaderIfMatch, etag)
+	api.expect(resp, body, fiber.StatusPreconditionFailed)
+
+	resp, body = api.do(http.MethodGet, "/api/v1/products?page=1&limit=10", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Product](t, body); len(got) != 1 || got[0].Price != 399 {
+		t.Fatalf("list = %+v", got)
+	}
+
+	resp, body = api.do(http.MethodDelete, path, nil, fiber.HeaderIfMatch, `"2-1-1"`)
+	api.expect(resp, body, fiber.StatusNoContent)
+	resp, body = api.do(http.MethodGet, path, nil)
+	api.expect(resp, body, fiber.StatusNotFound)
+}
+
+func TestProductValidation(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+
+	resp, body := api.do(http.MethodPost, "/api/v1/products", fiber.Map{"price": -1, "cover_image": "not a url"})
+	api.expect(resp, body, fiber.StatusBadRequest)
+	problem := decodeProblem(t, body)
+	if problem.Code != "validation_failed" {
+		t.Fatalf("code = %q, want validation_failed", problem.Code)
+	}
+	want := map[string]string{
+		"name": "required", "description": "required", "price": "gt",
+		"cover_image": "url", "category_id": "required", "brand_id": "required",
+	}
+	if got := fieldRules(problem); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("field errors = %v, want %v", got, want)
+	}
+
+	dangling := validProduct(category, brand)
+	dangling["category_id"], dangling["brand_id"] = 98, 99
+	resp, body = api.do(http.MethodPost, "/api/v1/products", dangling)
+	api.expect(resp, body, fiber.StatusBadRequest)
+	want = map[string]string{"category_id": "exists", "brand_id": "exists"}
+	if got := fieldRules(decodeProblem(t, body)); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("field errors = %v, want %v", got, want)
+	}
+
+	resp, body = api.do(http.MethodPost, "/api/v1/products", `{"name":`)
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if code := decodeProblem(t, body).Code; code != "invalid_body" {
+		t.Fatalf("code = %q, want invalid_body", code)
+	}
+
+	// Messages follow Accept-Language
+	resp, body = api.do(http.MethodPost, "/api/v1/products", fiber.Map{}, fiber.HeaderAcceptLanguage, "fr")
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if title := decodeProblem(t, body).Title; title != "Requête incorrecte" {
+		t.Fatalf("title = %q, want the French one", title)
+	}
+
+	// Nothing was created
+	if _, total, _ := api.products.List(context.Background(), repository.ProductQuery{Page: repository.Page{Limit: 10}}); total != 0 {
+		t.Fatalf("%d products were created", total)
+	}
+}
+
+func TestProductPatch(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+
+	resp, body := api.do(http.MethodPost, "/api/v1/products", validProduct(category, brand))
+	api.expect(resp, body, fiber.StatusCreated)
+	path := fmt.Sprintf("/api/v1/products/%d", decodeData[models.Product](t, body).ID)
+
+	resp, body = api.do(http.MethodPatch, path, `{"name":"Phone X2"}`,
+		fiber.HeaderContentType, "application/merge-patch+json")
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[models.Product](t, body); got.Name != "Phone X2" || got.Price != 499.5 {
+		t.Fatalf("merge-patched product = %q at %v", got.Name, got.Price)
+	}
+
+	resp, body = api.do(http.MethodPatch, path, `[{"op":"test","path":"/name","value":"Phone X2"},{"op":"replace","path":"/price","value":399}]`,
+		fiber.HeaderContentType, "application/json-patch+json")
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[models.Product](t, body); got.Name != "Phone X2" || got.Price != 399 {
+		t.Fatalf("JSON-patched product = %q at %v", got.Name, got.Price)
+	}
+
+	// A failed test operation leaves the product alone
+	resp, body = api.do(http.MethodPatch, path, `[{"op":"test","path":"/name","value":"Phone X"},{"op":"replace","path":"/price","value":1}]`,
+		fiber.HeaderContentType, "application/json-patch+json")
+	api.expect(resp, body, fiber.StatusConflict)
+	resp, body = api.do(http.MethodPatch, path, `[{"op":"remove","path":"/nope"}]`,
+		fiber.HeaderContentType, "application/json-patch+json")
+	api.expect(resp, body, fiber.StatusUnprocessableEntity)
+
+	resp, body = api.do(http.MethodPatch, path, `{"price":"cheap"}`,
+		fiber.HeaderContentType, "application/merge-patch+json")
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if rules := fieldRules(decodeProblem(t, body)); rules["price"] != "type" {
+		t.Fatalf("field errors = %v, want price type", rules)
+	}
+
+	resp, body = api.do(http.MethodPatch, path, `{"name":"Phone Y"}`)
+	api.expect(resp, body, fiber.StatusUnsupportedMediaType)
+	if resp.Header.Get("Accept-Patch") == "" {
+		t.Fatal("415 has no Accept-Patch")
+	}
+
+	resp, body = api.do(http.MethodGet, path, nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[models.Product](t, body); got.Name != "Phone X2" || got.Price != 399 {
+		t.Fatalf("stored product = %q at %v", got.Name, got.Price)
+	}
+}
+
+func TestProductNotFound(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		resp, body := api.do(method, "/api/v1/products/42", validProduct(category, brand))
+		api.expect(resp, body, fiber.StatusNotFound)
+		if code := decodeProblem(t, body).Code; code != "not_found" {
+			t.Fatalf("%s: code = %q, want not_found", method, code)
+		}
+
+		resp, body = api.do(method, "/api/v1/products/abc", validProduct(category, brand))
+		api.expect(resp, body, fiber.StatusBadRequest)
+		if code := decodeProblem(t, body).Code; code != "invalid_id" {
+			t.Fatalf("%s: code = %q, want invalid_id", method, code)
+		}
+	}
+}
+
+func TestProductListFilters(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	for _, price := range []float64{30, 10, 20} {
+		product := validProduct(category, brand)
+		product["price"] = price
+		resp, body := api.do(http.MethodPost, "/api/v1/products", product)
+		api.expect(resp, body, fiber.StatusCreated)
+	}
+
+	resp, body := api.do(http.MethodGet, "/api/v1/products?min_price=15&sort=price:desc&limit=1", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	got := decodeData[[]models.Product](t, body)
+	if len(got) != 1 || got[0].Price != 30 {
+		t.Fatalf("list = %+v, want the 30 product", got)
+	}
+	meta := decodeMeta(t, body)
+	if meta.Total == nil || *meta.Total != 2 || *meta.TotalPages != 2 || meta.Prev != "" {
+		t.Fatalf("meta = %+v, want 2 products on 2 pages", meta)
+	}
+	resp, body = api.do(http.MethodGet, meta.Next, nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Product](t, body); len(got) != 1 || got[0].Price != 20 {
+		t.Fatalf("next page = %+v, want the 20 product", got)
+	}
+
+	for query, detail := range map[string]string{
+		"sort=brand_id":             "invalid sort field 'brand_id'",
+		"sort=price:up":             "invalid sort direction 'up' for 'price'",
+		"min_price=20&max_price=10": "min_price must not exceed max_price",
+	} {
+		resp, body = api.do(http.MethodGet, "/api/v1/products?"+query, nil)
+		api.expect(resp, body, fiber.StatusBadRequest)
+		if problem := decodeProblem(t, body); problem.Code != "invalid_query" || problem.Detail != detail {
+			t.Fatalf("%s: problem = %s, want invalid_query %q", query, body, detail)
+		}
+	}
+}
+
+func TestProductCursorPaging(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	for _, price := range []float64{40, 10, 30, 20} {
+		product := validProduct(category, brand)
+		product["price"] = price
+		resp, body := api.do(http.MethodPost, "/api/v1/products", product)
+		api.expect(resp, body, fiber.StatusCreated)
+	}
+
+	resp, body := api.do(http.MethodGet, "/api/v1/products?sort=price&limit=2", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	meta := decodeMeta(t, body)
+	if meta.NextCursor == "" {
+		t.Fatal("first page has no next_cursor")
+	}
+
+	resp, body = api.do(http.MethodGet, "/api/v1/products?sort=price&limit=2&after="+meta.NextCursor, nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Product](t, body); len(got) != 2 || got[0].Price != 30 || got[1].Price != 40 {
+		t.Fatalf("page after the cursor = %+v, want 30 and 40", got)
+	}
+	meta = decodeMeta(t, body)
+	if meta.Total != nil || meta.NextCursor != "" || meta.PrevCursor == "" {
+		t.Fatalf("last cursor page meta = %+v", meta)
+	}
+
+	// A cursor is bound to its sort order and cannot be edited
+	for _, query := range []string{
+		"sort=price:desc&after=" + meta.PrevCursor,
+		"sort=price&after=x" + meta.PrevCursor,
+	} {
+		resp, body = api.do(http.MethodGet, "/api/v1/products?"+query, nil)
+		api.expect(resp, body, fiber.StatusBadRequest)
+		if detail := decodeProblem(t, body).Detail; detail != "Invalid cursor" {
+			t.Fatalf("detail = %q, want Invalid cursor", detail)
+		}
+	}
+}
+
+// racingProducts lets another writer update each product right after the
+// handler has read it, so the handler's own write meets a newer version.
+type racingProducts struct {
+	repository.ProductRepository
+}
+
+func (r racingProducts) FindByID(ctx context.Context, id uint) (*models.Product, error) {
+	product, err := r.ProductRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	concurrent := *product
+	concurrent.Description = "Changed by someone else"
+	if err := r.ProductRepository.Update(ctx, &concurrent); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func TestProductVersionConflict(t *testing.T) {
+	categories := repository.NewMemoryCategoryRepository()
+	brands := repository.NewMemoryBrandRepository()
+	products := repository.NewMemoryProductRepository(categories, brands)
+	api := newTestAPIWith(t, racingProducts{products}, categories, brands)
+	category, brand := api.seedReferences()
+
+	product := models.Product{
+		Name: "Phone X", Description: "A phone", Price: 499.5, CoverImage: "https://example.com/phone-x.png",
+		CategoryID: category.ID, BrandID: brand.ID,
+	}
+	if err := products.Create(context.Background(), &product); err != nil {
+		t.Fatal(err)
+	}
+	path := fmt.Sprintf("/api/v1/products/%d", product.ID)
+
+	// Without If-Match the lost race is a 409; with it, the tag the client
+	// sent no longer holds, so 412
+	resp, body := api.do(http.MethodPut, path, validProduct(category, brand))
+	api.expect(resp, body, fiber.StatusConflict)
+	resp, body = api.do(http.MethodPut, path, validProduct(category, brand), fiber.HeaderIfMatch, `"2-1-1"`)
+	api.expect(resp, body, fiber.StatusPreconditionFailed)
+	resp, body = api.do(http.MethodDelete, path, nil)
+	api.expect(resp, body, fiber.StatusConflict)
+
+	stored, err := products.FindByID(context.Background(), product.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Description != "Changed by someone else" || stored.Price != 499.5 {
+		t.Fatalf("a conflicting write was saved: %+v", stored)
+	}
+}
+
+func TestProductListCaching(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	for i := 0; i < 3; i++ {
+		resp, body := api.do(http.MethodPost, "/api/v1/products", validProduct(category, brand))
+		api.expect(resp, body, fiber.StatusCreated)
+	}
+
+	resp, body := api.do(http.MethodGet, "/api/v1/products?limit=10", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	etag := resp.Header.Get(fiber.HeaderETag)
+	if etag == "" {
+		t.Fatal("list has no ETag")
+	}
+
+	lastModified := resp.Header.Get(fiber.HeaderLastModified)
+	if lastModified == "" {
+		t.Fatal("list has no Last-Modified")
+	}
+
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfNoneMatch, etag)
+	api.expect(resp, body, fiber.StatusNotModified)
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfModifiedSince, lastModified)
+	api.expect(resp, body, fiber.StatusNotModified)
+
+	// A deletion leaves every remaining updated_at alone, but changes the page
+	// and moves Last-Modified, which has one-second resolution
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	resp, body = api.do(http.MethodDelete, "/api/v1/products/3", nil)
+	api.expect(resp, body, fiber.StatusNoContent)
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfNoneMatch, etag)
+	api.expect(resp, body, fiber.StatusOK)
+	etag = resp.Header.Get(fiber.HeaderETag)
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfModifiedSince, lastModified)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Product](t, body); len(got) != 2 {
+		t.Fatalf("list has %d products after a deletion, want 2", len(got))
+	}
+	if resp.Header.Get(fiber.HeaderLastModified) == lastModified {
+		t.Fatal("a deletion did not move Last-Modified")
+	}
+
+	// So does a change to a category the page embeds
+	lastModified = resp.Header.Get(fiber.HeaderLastModified)
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	renamed := category
+	renamed.Title = "Smartphones"
+	if err := api.categories.Update(context.Background(), &renamed); err != nil {
+		t.Fatal(err)
+	}
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfModifiedSince, lastModified)
+	api.expect(resp, body, fiber.StatusOK)
+
+	// Search is dated the same way
+	resp, body = api.do(http.MethodGet, "/api/v1/products/search?q=phone", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := resp.Header.Get(fiber.HeaderLastModified); got == "" {
+		t.Fatal("search has no Last-Modified")
+	}
+
+	// A change to the page changes its tag
+	update := validProduct(category, brand)
+	update["price"] = 399.0
+	resp, body = api.do(http.MethodPut, "/api/v1/products/2", update)
+	api.expect(resp, body, fiber.StatusOK)
+	resp, body = api.do(http.MethodGet, "/api/v1/products?limit=10", nil, fiber.HeaderIfNoneMatch, etag)
+	api.expect(resp, body, fiber.StatusOK)
+	if got := decodeData[[]models.Product](t, body); len(got) != 2 || got[1].Price != 399 {
+		t.Fatalf("list after an update = %+v", got)
+	}
+}
+
+func TestProductBulk(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	invalid := validProduct(category, brand)
+	invalid["price"] = -1
+
+	// Atomic by default: one invalid item and nothing is created
+	resp, body := api.do(http.MethodPost, "/api/v1/products/bulk", []fiber.Map{validProduct(category, brand), invalid})
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if rules := fieldRules(decodeProblem(t, body)); rules["[1].price"] != "gt" {
+		t.Fatalf("field errors = %v, want [1].price gt", rules)
+	}
+	if _, total, _ := api.products.List(context.Background(), repository.ProductQuery{Page: repository.Page{Limit: 10}}); total != 0 {
+		t.Fatalf("%d products were created", total)
+	}
+
+	resp, body = api.do(http.MethodPost, "/api/v1/products/bulk?mode=partial", []fiber.Map{validProduct(category, brand), invalid, validProduct(category, brand)})
+	api.expect(resp, body, fiber.StatusMultiStatus)
+	result := decodeData[models.BulkResult](t, body)
+	if result.Succeeded != 2 || result.Failed != 1 || result.Items[1].Code != "validation_failed" {
+		t.Fatalf("partial create = %+v", result)
+	}
+	first, second := result.Items[0].ID, result.Items[2].ID
+
+	// A stale version fails its item like If-Match
+	update := validProduct(category, brand)
+	update["id"], update["version"], update["price"] = first, 1, 10.0
+	stale := validProduct(category, brand)
+	stale["id"], stale["version"] = second, 7
+	resp, body = api.do(http.MethodPut, "/api/v1/products/bulk", []fiber.Map{update, stale})
+	api.expect(resp, body, fiber.StatusPreconditionFailed)
+	resp, body = api.do(http.MethodPut, "/api/v1/products/bulk?mode=partial", []fiber.Map{update, stale})
+	api.expect(resp, body, fiber.StatusMultiStatus)
+	if result := decodeData[models.BulkResult](t, body); result.Items[0].Status != fiber.StatusOK || result.Items[1].Status != fiber.StatusPreconditionFailed {
+		t.Fatalf("partial update = %+v", result)
+	}
+
+	resp, body = api.do(http.MethodDelete, "/api/v1/products/bulk", []fiber.Map{{"id": first}, {"id": second}})
+	api.expect(resp, body, fiber.StatusOK)
+	if result := decodeData[models.BulkResult](t, body); result.Succeeded != 2 {
+		t.Fatalf("delete = %+v", result)
+	}
+	if _, total, _ := api.products.List(context.Background(), repository.ProductQuery{Page: repository.Page{Limit: 10}}); total != 0 {
+		t.Fatalf("%d products are left", total)
+	}
+
+	resp, body = api.do(http.MethodDelete, "/api/v1/products/bulk?mode=sometimes", []fiber.Map{{"id": first}})
+	api.expect(resp, body, fiber.StatusBadRequest)
+	if code := decodeProblem(t, body).Code; code != "invalid_query" {
+		t.Fatalf("code = %q, want invalid_query", code)
+	}
+}
+
+func TestProductImport(t *testing.T) {
+	api := newTestAPI(t)
+	api.seedReferences()
+	feed := "name,description,price,cover_image,category,brand\n" +
+		"Phone X,A phone,499.5,https://example.com/x.png,Phones,Acme\n" +
+		"Phone Y,A phone,-1,https://example.com/y.png,Phones,Acme\n"
+
+	resp, body := api.do(http.MethodPost, "/api/v1/products/import", feed, fiber.HeaderContentType, "text/csv")
+	api.expect(resp, body, fiber.StatusMultiStatus)
+	if report := decodeData[models.ImportReport](t, body); report.Created != 1 || report.Failed != 1 || report.Errors[0].Row != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	resp, body = api.do(http.MethodPost, "/api/v1/products/import", "name\n", fiber.HeaderContentType, "text/csv")
+	api.expect(resp, body, fiber.StatusBadRequest)
+	resp, body = api.do(http.MethodPost, "/api/v1/products/import", feed, fiber.HeaderContentType, "text/plain")
+	api.expect(resp, body, fiber.StatusUnsupportedMediaType)
+}
+
+func TestProductExport(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	for _, name := range []string{"Phone X", "Tablet Y"} {
+		product := validProduct(category, brand)
+		product["name"] = name
+		resp, body := api.do(http.MethodPost, "/api/v1/products", product)
+		api.expect(resp, body, fiber.StatusCreated)
+	}
+
+	resp, body := api.do(http.MethodGet, "/api/v1/products/export?name=phone", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	if disposition := resp.Header.Get(fiber.HeaderContentDisposition); !strings.HasPrefix(disposition, `attachment; filename="products-`) {
+		t.Fatalf("Content-Disposition = %q", disposition)
+	}
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "1,Phone X,") {
+		t.Fatalf("export = %q, want the header and Phone X", body)
+	}
+
+	resp, body = api.do(http.MethodGet, "/api/v1/products/export?format=pdf", nil)
+	api.expect(resp, body, fiber.StatusBadRequest)
+}
+
+func TestProductSearch(t *testing.T) {
+	api := newTestAPI(t)
+	category, brand := api.seedReferences()
+	for _, name := range []string{"Wireless headphones", "Phone case"} {
+		product := validProduct(category, brand)
+		product["name"] = name
+		resp, body := api.do(http.MethodPost, "/api/v1/products", product)
+		api.expect(resp, body, fiber.StatusCreated)
+	}
+
+	resp, body := api.do(http.MethodGet, "/api/v1/products/search?q=head", nil)
+	api.expect(resp, body, fiber.StatusOK)
+	hits := decodeData[[]models.SearchHit](t, body)
+	if len(hits) != 1 || hits[0].Highlights.Name != "Wireless <mark>head</mark>phones" {
+		t.Fatalf("hits = %+v", hits)
+	}
+
+	for _, q := range []string{"", "%20%20", strings.Repeat("a", 201)} {
+		resp, body = api.do(http.MethodGet, "/api/v1/products/search?q="+q, nil)
+		api.expect(resp, body, fiber.StatusBadRequest)
+		if rules := fieldRules(decodeProblem(t, body)); rules["q"] == "" {
+			t.Fatalf("q %.10q: field errors = %v, want one on q", q, rules)
+		}
+	}
+}
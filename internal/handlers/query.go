@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageLimit = 10
+	maxPageLimit     = 100
+)
+
+// pagination holds the page/limit query parameters shared by all list endpoints.
+type pagination struct {
+	Page  int
+	Limit int
+}
+
+// parsePagination reads page and limit, falling back to defaults for missing
+// or invalid values and capping limit at maxPageLimit.
+func parsePagination(c *fiber.Ctx) pagination {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultPageLimit)))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	return pagination{Page: page, Limit: limit}
+}
+
+// window converts the page number into a repository offset/limit pair.
+func (p pagination) window() repository.Page {
+	return repository.Page{Offset: (p.Page - 1) * p.Limit, Limit: p.Limit}
+}
+
+// meta builds the response meta block, including relative next/prev links
+// that preserve every other query parameter of the current request.
+func (p pagination) meta(c *fiber.Ctx, total int64) *models.PaginationMeta {
+	totalPages := int((total + int64(p.Limit) - 1) / int64(p.Limit))
+
+	meta := &models.PaginationMeta{
+		Total:      &total,
+		Page:       p.Page,
+		Limit:      p.Limit,
+		TotalPages: &totalPages,
+	}
+	if p.Page < totalPages {
+		meta.Next = pageLink(c, p.Page+1, p.Limit)
+	}
+	if p.Page > 1 && totalPages > 0 {
+		meta.Prev = pageLink(c, min(p.Page-1, totalPages), p.Limit)
+	}
+	return meta
+}
+
+// pageLink returns the current path with page and limit replaced.
+func pageLink(c *fiber.Ctx, page, limit int) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	c.Context().QueryArgs().CopyTo(args)
+	args.Del("after")
+	args.Del("before")
+	args.Set("page", strconv.Itoa(page))
+	args.Set("limit", strconv.Itoa(limit))
+	return c.Path() + "?" + args.String()
+}
+
+// cursorLink returns the current path with page, after and before replaced by
+// a single after= or before= cursor.
+func cursorLink(c *fiber.Ctx, key, cursor string, limit int) string {
+	args := fasthttp.AcquireArgs()
+	defer fasthttp.ReleaseArgs(args)
+
+	c.Context().QueryArgs().CopyTo(args)
+	args.Del("page")
+	args.Del("after")
+	args.Del("before")
+	args.Set(key, cursor)
+	args.Set("limit", strconv.Itoa(limit))
+	return c.Path() + "?" + args.String()
+}
+
+// productListParams holds the filter query parameters accepted by GetAllProducts.
+type productListParams struct {
+	CategoryID *uint    `query:"category_id" validate:"omitempty,gt=0"`
+	BrandID    *uint    `query:"brand_id" validate:"omitempty,gt=0"`
+	MinPrice   *float64 `query:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice   *float64 `query:"max_price" validate:"omitempty,gte=0"`
+	Name       string   `query:"name" validate:"max=100"`
+	Sort       string   `query:"sort" validate:"max=200"`
+}
+
+// queryError is a malformed query parameter. key is an English translation key
+// whose placeholders are filled from params.
+type queryError struct {
+	key    string
+	params []string
+}
+
+func (e *queryError) Error() string {
+	return i18n.Format(e.key, e.params...)
+}
+
+// invalidQuery writes an invalid_query problem for err, translated when it is
+// a queryError.
+func invalidQuery(c *fiber.Ctx, err error) error {
+	var queryErr *queryError
+	if errors.As(err, &queryErr) {
+		return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery, queryErr.key, queryErr.params...)
+	}
+	return respond.Problem(c, fiber.StatusBadRequest, respond.CodeInvalidQuery, err.Error())
+}
+
+// parseProductListing parses the filter and sort parameters shared by the
+// product listing and export. Write its error with listingRejected.
+func parseProductListing(c *fiber.Ctx) (repository.ProductFilter, []repository.SortField, error) {
+	var params productListParams
+	if err := c.QueryParser(&params); err != nil {
+		return repository.ProductFilter{}, nil, &queryError{key: "Invalid query parameters"}
+	}
+	if err := validateProduct.Struct(params); err != nil {
+		return repository.ProductFilter{}, nil, err
+	}
+	filter, err := params.filter()
+	if err != nil {
+		return repository.ProductFilter{}, nil, err
+	}
+	sort, err := parseSort(params.Sort, repository.ProductSortFields)
+	if err != nil {
+		return repository.ProductFilter{}, nil, err
+	}
+	return filter, sort, nil
+}
+
+// listingRejected writes the problem for an error returned by
+// parseProductListing.
+func listingRejected(c *fiber.Ctx, err error) error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return respond.ValidationFailed(c, err)
+	}
+	return invalidQuery(c, err)
+}
+
+// filter converts the parsed parameters into a repository filter.
+func (p productListParams) filter() (repository.ProductFilter, error) {
+	if p.MinPrice != nil && p.MaxPrice != nil && *p.MinPrice > *p.MaxPrice {
+		return repository.ProductFilter{}, &queryError{key: "min_price must not exceed max_price"}
+	}
+
+	return repository.ProductFilter{
+		CategoryID: p.CategoryID,
+		BrandID:    p.BrandID,
+		MinPrice:   p.MinPrice,
+		MaxPrice:   p.MaxPrice,
+		Name:       strings.TrimSpace(p.Name),
+	}, nil
+}
+
+// ParseProductSort parses a product sort expression as GET /products does,
+// for callers outside HTTP such as the export command.
+func ParseProductSort(raw string) ([]repository.SortField, error) {
+	return parseSort(raw, repository.ProductSortFields)
+}
+
+// parseSort parses a sort expression such as "price:desc,name" into sort
+// fields. Every field must appear in allowed and the direction, when given,
+// must be "asc" or "desc".
+func parseSort(raw string, allowed map[string]string) ([]repository.SortField, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var fields []repository.SortField
+	seen := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		name, dir, _ := strings.Cut(strings.TrimSpace(part), ":")
+
+		if _, ok := allowed[name]; !ok {
+			return nil, &queryError{key: "invalid sort field '{0}'", params: []string{name}}
+		}
+		if seen[name] {
+			return nil, &queryError{key: "duplicate sort field '{0}'", params: []string{name}}
+		}
+		seen[name] = true
+
+		switch strings.ToLower(dir) {
+		case "", "asc":
+			fields = append(fields, repository.SortField{Field: name})
+		case "desc":
+			fields = append(fields, repository.SortField{Field: name, Desc: true})
+		default:
+			return nil, &queryError{key: "invalid sort direction '{0}' for '{1}'", params: []string{dir, name}}
+		}
+	}
+
+	return fields, nil
+}
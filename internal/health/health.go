@@ -0,0 +1,110 @@
+// Package health tracks whether this instance should receive traffic.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check is one readiness dependency check. Run must honour ctx cancellation.
+type Check struct {
+	Name string
+	// Critical checks fail readiness; others are only reported.
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of one Check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok or fail
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of a readiness probe.
+type Report struct {
+	Status string   `json:"status"` // ok, fail or draining
+	Checks []Result `json:"checks"`
+}
+
+// Ready reports whether the instance should receive traffic.
+func (r Report) Ready() bool {
+	return r.Status == "ok"
+}
+
+// State is the process-wide readiness state. It starts ready and flips to
+// draining once shutdown begins, so load balancers stop routing new requests
+// here while in-flight ones finish.
+type State struct {
+	draining atomic.Bool
+	checks   []Check
+}
+
+// NewState returns a ready State with no checks.
+func NewState() *State {
+	return &State{}
+}
+
+// AddCheck registers a readiness check. Register all checks before serving.
+func (s *State) AddCheck(check Check) {
+	s.checks = append(s.checks, check)
+}
+
+// StartDraining marks the instance as shutting down. It cannot be undone.
+func (s *State) StartDraining() {
+	s.draining.Store(true)
+}
+
+// Draining reports whether shutdown has begun.
+func (s *State) Draining() bool {
+	return s.draining.Load()
+}
+
+// Probe runs every check concurrently, each bounded by timeout.
+func (s *State) Probe(ctx context.Context, timeout time.Duration) Report {
+	results := make([]Result, len(s.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check, timeout)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: "ok", Checks: results}
+	for _, result := range results {
+		if result.Critical && result.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+	if s.Draining() {
+		report.Status = "draining"
+	}
+	return report
+}
+
+func run(ctx context.Context, check Check, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{
+		Name:      check.Name,
+		Status:    "ok",
+		Critical:  check.Critical,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	state := NewState()
+	state.AddCheck(Check{Name: "database", Critical: true, Run: func(context.Context) error { return nil }})
+	state.AddCheck(Check{Name: "cache", Run: func(context.Context) error { return errors.New("down") }})
+
+	report := state.Probe(context.Background(), time.Second)
+	if !report.Ready() {
+		t.Fatalf("a failing non-critical check made the report %q", report.Status)
+	}
+	if report.Checks[1].Status != "fail" || report.Checks[1].Error != "down" {
+		t.Fatalf("cache result = %+v", report.Checks[1])
+	}
+
+	// A critical check that outlives the timeout fails
+	state.AddCheck(Check{Name: "migrations", Critical: true, Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	report = state.Probe(context.Background(), 10*time.Millisecond)
+	if report.Status != "fail" || report.Checks[2].Error != context.DeadlineExceeded.Error() {
+		t.Fatalf("report = %+v, want a failed migrations check", report)
+	}
+
+	state.StartDraining()
+	if report = state.Probe(context.Background(), 10*time.Millisecond); report.Status != "draining" {
+		t.Fatalf("status while draining = %q", report.Status)
+	}
+}
@@ -0,0 +1,157 @@
+// Package i18n translates API messages with go-playground/universal-translator.
+// Messages are keyed by their English text, so a missing translation falls
+// back to English; "{0}", "{1}"... are placeholders for parameters, and must
+// appear in order in every translation.
+//
+// French and Spanish catalogs are built in; Load adds or overrides entries
+// from universal-translator JSON files.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+)
+
+//go:embed catalogs/*.json
+var catalogs embed.FS
+
+// universal holds every supported locale; English is the fallback.
+var universal = ut.New(en.New(), en.New(), fr.New(), es.New())
+
+// validatorTranslations registers validator's built-in messages per locale.
+var validatorTranslations = map[string]func(*validator.Validate, ut.Translator) error{
+	"en": en_translations.RegisterDefaultTranslations,
+	"fr": fr_translations.RegisterDefaultTranslations,
+	"es": es_translations.RegisterDefaultTranslations,
+}
+
+type translatorKey struct{}
+
+func init() {
+	err := fs.WalkDir(catalogs, "catalogs", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := catalogs.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return universal.ImportByReader(ut.FormatJSON, f)
+	})
+	if err != nil {
+		panic(fmt.Sprintf("i18n: built-in catalogs: %v", err))
+	}
+}
+
+// Load imports translation files (universal-translator JSON: a list of
+// {"locale", "key", "trans"} objects) from a file or directory. Entries for
+// keys that already exist must set "override": true.
+func Load(path string) error {
+	return universal.Import(ut.FormatJSON, path)
+}
+
+// RegisterValidator adds validator's own rule messages in every supported
+// locale, so FieldError.Translate works with any translator from Match.
+func RegisterValidator(validate *validator.Validate) error {
+	for locale, register := range validatorTranslations {
+		trans, _ := universal.GetTranslator(locale)
+		if err := register(validate, trans); err != nil {
+			return fmt.Errorf("i18n: validator translations for %s: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// Match returns the translator that best satisfies an Accept-Language
+// header, honouring q-values and falling back from "fr-CA" to "fr" and
+// finally to English.
+func Match(acceptLanguage string) ut.Translator {
+	type tag struct {
+		locale string
+		q      float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{locale: strings.ReplaceAll(locale, "-", "_"), q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	candidates := make([]string, 0, 2*len(tags))
+	for _, t := range tags {
+		candidates = append(candidates, t.locale)
+		if base, _, ok := strings.Cut(t.locale, "_"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	trans, _ := universal.FindTranslator(candidates...)
+	return trans
+}
+
+// Fallback returns the English translator.
+func Fallback() ut.Translator {
+	return universal.GetFallback()
+}
+
+// WithTranslator returns a copy of ctx carrying trans.
+func WithTranslator(ctx context.Context, trans ut.Translator) context.Context {
+	return context.WithValue(ctx, translatorKey{}, trans)
+}
+
+// FromContext returns the translator stored in ctx, or the English one.
+func FromContext(ctx context.Context) ut.Translator {
+	if trans, ok := ctx.Value(translatorKey{}).(ut.Translator); ok {
+		return trans
+	}
+	return Fallback()
+}
+
+// T translates key, falling back to key itself (English) with the
+// placeholders filled in when trans has no usable translation.
+func T(trans ut.Translator, key string, params ...string) (msg string) {
+	defer func() {
+		// A catalog entry with more placeholders than params makes ut panic
+		if recover() != nil {
+			msg = Format(key, params...)
+		}
+	}()
+
+	if msg, err := trans.T(key, params...); err == nil {
+		return msg
+	}
+	return Format(key, params...)
+}
+
+// Format fills the "{0}", "{1}"... placeholders of msg.
+func Format(msg string, params ...string) string {
+	for i, param := range params {
+		msg = strings.ReplaceAll(msg, "{"+strconv.Itoa(i)+"}", param)
+	}
+	return msg
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"encoding/json"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"":                          "en",
+		"fr":                        "fr",
+		"fr-CA":                     "fr",
+		"de, es;q=0.8":              "es",
+		"es;q=0.5, fr;q=0.9":        "fr",
+		"fr;q=0, es":                "es",
+		"*":                         "en",
+		"de-AT, it":                 "en",
+		"en-GB;q=0.7, fr-FR;q=0.71": "fr",
+	}
+	for header, want := range tests {
+		if got := Match(header).Locale(); got != want {
+			t.Errorf("Match(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	fr := Match("fr")
+	if got := T(fr, "Bad Request"); got != "Requête incorrecte" {
+		t.Fatalf("French Bad Request = %q", got)
+	}
+	if got := T(fr, "No translation for {0}", "this"); got != "No translation for this" {
+		t.Fatalf("untranslated key = %q, want the English text filled in", got)
+	}
+	if got := T(Fallback(), "Bad Request"); got != "Bad Request" {
+		t.Fatalf("English Bad Request = %q", got)
+	}
+}
+
+// TestCatalogsComplete keeps the built-in catalogs in step: every key is
+// translated in every locale, with the same placeholders.
+func TestCatalogsComplete(t *testing.T) {
+	placeholder := regexp.MustCompile(`\{\d+\}`)
+	keys := map[string]map[string]string{}
+	for _, locale := range []string{"fr", "es"} {
+		data, err := catalogs.ReadFile("catalogs/" + locale + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var entries []struct {
+			Locale string `json:"locale"`
+			Key    string `json:"key"`
+			Trans  string `json:"trans"`
+		}
+		if err := json.Unmarshal(data, &entries); err != nil {
+			t.Fatalf("%s.json: %v", locale, err)
+		}
+		for _, entry := range entries {
+			if entry.Locale != locale {
+				t.Errorf("%s.json has an entry for %s: %q", locale, entry.Locale, entry.Key)
+			}
+			if !slices.Equal(placeholder.FindAllString(entry.Key, -1), placeholder.FindAllString(entry.Trans, -1)) {
+				t.Errorf("%s: %q and %q use different placeholders", locale, entry.Key, entry.Trans)
+			}
+			if keys[entry.Key] == nil {
+				keys[entry.Key] = map[string]string{}
+			}
+			keys[entry.Key][locale] = entry.Trans
+		}
+	}
+	for key, translations := range keys {
+		if len(translations) != 2 {
+			t.Errorf("%q is only translated in %v", key, translations)
+		}
+	}
+}
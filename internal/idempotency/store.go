@@ -0,0 +1,139 @@
+// Package idempotency stores the outcome of POST requests sent with an
+// Idempotency-Key so retries can be answered without repeating the write.
+package idempotency
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pruneInterval is how often the store drops expired records.
+const pruneInterval = time.Minute
+
+// ErrInFlight is returned by Begin while another request with the same key
+// is still being processed.
+var ErrInFlight = errors.New("request with this idempotency key is in flight")
+
+// ErrMismatch is returned by Begin when the key was used for a different
+// request.
+var ErrMismatch = errors.New("idempotency key reused with a different request")
+
+// Store keeps records in the idempotency_records table, so every replica
+// sharing the database sees the same keys.
+type Store struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	nextPrune time.Time
+}
+
+// NewStore returns a Store backed by db. The idempotency_records table must
+// already exist.
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// Begin claims key for the caller identified by scope. It returns nil if the
+// request should be processed, now holding the key until lockFor passes or
+// Complete or Release is called, or the stored record to replay. A key that
+// is held by another request yields ErrInFlight, one that was used for a
+// request with a different fingerprint ErrMismatch.
+func (s *Store) Begin(ctx context.Context, scope, key, fingerprint string, lockFor time.Duration) (*models.IdempotencyRecord, error) {
+	s.prune(ctx)
+
+	now := time.Now()
+	record := models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(lockFor).Unix(),
+	}
+
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	// Take over a record that expired but has not been pruned yet
+	res = s.db.WithContext(ctx).
+		Model(&models.IdempotencyRecord{}).
+		Where("scope = ? AND idempotency_key = ? AND expires_at < ?", scope, key, now.Unix()).
+		Updates(map[string]interface{}{
+			"fingerprint":  fingerprint,
+			"status_code":  0,
+			"content_type": "",
+			"body":         "",
+			"created_at":   now,
+			"expires_at":   record.ExpiresAt,
+		})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var stored models.IdempotencyRecord
+	if err := s.db.WithContext(ctx).Where("scope = ? AND idempotency_key = ?", scope, key).First(&stored).Error; err != nil {
+		return nil, err
+	}
+	switch {
+	case stored.Fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case stored.StatusCode == 0:
+		return nil, ErrInFlight
+	}
+	return &stored, nil
+}
+
+// Complete stores the response to a request claimed with Begin and keeps it
+// for ttl.
+func (s *Store) Complete(ctx context.Context, scope, key string, status int, contentType string, body []byte, ttl time.Duration) error {
+	return s.db.WithContext(ctx).
+		Model(&models.IdempotencyRecord{}).
+		Where("scope = ? AND idempotency_key = ?", scope, key).
+		Updates(map[string]interface{}{
+			"status_code":  status,
+			"content_type": contentType,
+			"body":         string(body),
+			"expires_at":   time.Now().Add(ttl).Unix(),
+		}).Error
+}
+
+// Release gives up a key claimed with Begin without storing a response, so
+// a retry runs the request again.
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	return s.db.WithContext(ctx).
+		Where("scope = ? AND idempotency_key = ? AND status_code = 0", scope, key).
+		Delete(&models.IdempotencyRecord{}).Error
+}
+
+// prune deletes expired records at most once per pruneInterval per process.
+func (s *Store) prune(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := now.After(s.nextPrune)
+	if due {
+		s.nextPrune = now.Add(pruneInterval)
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now.Unix()).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+		slog.WarnContext(ctx, "Failed to prune idempotency records", "error", err)
+	}
+}
@@ -0,0 +1,244 @@
+package importer
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Format is the encoding of an import feed.
+type Format string
+
+const (
+	CSV    Format = "csv"    // a header row, then one product per record
+	NDJSON Format = "ndjson" // one JSON object per line
+)
+
+// Media types of the feed formats.
+const (
+	MIMECSV    = "text/csv"
+	MIMENDJSON = "application/x-ndjson"
+)
+
+// maxLineSize bounds one NDJSON line.
+const maxLineSize = 1 << 20
+
+// FormatFor returns the format of a media type such as text/csv.
+func FormatFor(mediaType string) (Format, bool) {
+	switch mediaType {
+	case MIMECSV, "application/csv":
+		return CSV, true
+	case MIMENDJSON, "application/ndjson", "application/jsonl":
+		return NDJSON, true
+	}
+	return "", false
+}
+
+// FeedError is a feed that cannot be imported at all, such as a CSV file
+// without a required column. Key is an English translation key whose
+// placeholders are filled from Params; "{0}" is always the line.
+type FeedError struct {
+	Key    string
+	Params []string
+}
+
+func (e *FeedError) Error() string {
+	return i18n.Format(e.Key, e.Params...)
+}
+
+func feedError(line int, key string, params ...string) *FeedError {
+	return &FeedError{Key: key, Params: append([]string{strconv.Itoa(line)}, params...)}
+}
+
+// row is one product of a feed, with its category and brand by name. The
+// cover images are only used to create a missing category or brand.
+type row struct {
+	Name               string  `json:"name"`
+	Description        string  `json:"description"`
+	Price              float64 `json:"price"`
+	CoverImage         string  `json:"cover_image"`
+	Category           string  `json:"category"`
+	Brand              string  `json:"brand"`
+	CategoryCoverImage string  `json:"category_cover_image"`
+	BrandCoverImage    string  `json:"brand_cover_image"`
+}
+
+// rowError is a problem found while reading a row; field is empty when it
+// concerns the whole row. key is a translation key whose "{0}" is param.
+type rowError struct {
+	field, rule, param, key string
+}
+
+// feed yields the rows of an import file one at a time.
+type feed interface {
+	// next returns the next row and the line it starts on, or io.EOF. A row
+	// that could not be decoded comes with its errors.
+	next() (line int, r row, errs []rowError, err error)
+}
+
+func newFeed(format Format, r io.Reader) (feed, error) {
+	switch format {
+	case CSV:
+		return newCSVFeed(r)
+	case NDJSON:
+		return newNDJSONFeed(r), nil
+	}
+	return nil, fmt.Errorf("unknown import format %q", format)
+}
+
+// csvColumns are the columns a CSV feed may have; the first six are
+// required.
+var csvColumns = []string{
+	"name", "description", "price", "cover_image", "category", "brand",
+	"category_cover_image", "brand_cover_image",
+}
+
+var requiredColumns = csvColumns[:6]
+
+// csvFeed reads a CSV feed whose header row names its columns, in any order.
+type csvFeed struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVFeed(r io.Reader) (*csvFeed, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, feedError(1, "Line {0}: the feed is empty")
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, feedError(1, "Line {0}: the header row cannot be read")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // byte order mark
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(csvColumns, name) {
+			return nil, feedError(1, "Line {0}: unknown column {1}", name)
+		}
+		if _, dup := columns[name]; dup {
+			return nil, feedError(1, "Line {0}: column {1} appears twice", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range requiredColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, feedError(1, "Line {0}: missing column {1}", name)
+		}
+	}
+
+	reader.FieldsPerRecord = len(header)
+	return &csvFeed{r: reader, columns: columns}, nil
+}
+
+func (f *csvFeed) next() (int, row, []rowError, error) {
+	record, err := f.r.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, row{}, []rowError{{rule: "csv", param: parseErr.Err.Error(), key: "Malformed CSV record: {0}"}}, nil
+	}
+	if err != nil {
+		return 0, row{}, nil, err
+	}
+	line, _ := f.r.FieldPos(0)
+
+	get := func(column string) string {
+		if i, ok := f.columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	r := row{
+		Name:               get("name"),
+		Description:        get("description"),
+		CoverImage:         get("cover_image"),
+		Category:           get("category"),
+		Brand:              get("brand"),
+		CategoryCoverImage: get("category_cover_image"),
+		BrandCoverImage:    get("brand_cover_image"),
+	}
+
+	var errs []rowError
+	if price := get("price"); price != "" {
+		if r.Price, err = strconv.ParseFloat(price, 64); err != nil {
+			errs = append(errs, rowError{field: "price", rule: "type", param: "number", key: "Must be a {0}"})
+		}
+	}
+	return line, r, errs, nil
+}
+
+// ndjsonFeed reads one JSON object per line, skipping blank lines.
+type ndjsonFeed struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func newNDJSONFeed(r io.Reader) *ndjsonFeed {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &ndjsonFeed{s: s}
+}
+
+func (f *ndjsonFeed) next() (int, row, []rowError, error) {
+	for f.s.Scan() {
+		f.line++
+		data := bytes.TrimSpace(f.s.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var r row
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&r); err != nil {
+			return f.line, row{}, []rowError{jsonRowError(err)}, nil
+		}
+		r.Name = strings.TrimSpace(r.Name)
+		r.Category = strings.TrimSpace(r.Category)
+		r.Brand = strings.TrimSpace(r.Brand)
+		return f.line, r, nil, nil
+	}
+
+	if errors.Is(f.s.Err(), bufio.ErrTooLong) {
+		return 0, row{}, nil, feedError(f.line+1, "Line {0} is longer than {1} bytes", strconv.Itoa(maxLineSize))
+	}
+	if err := f.s.Err(); err != nil {
+		return 0, row{}, nil, err
+	}
+	return 0, row{}, nil, io.EOF
+}
+
+// jsonRowError describes an NDJSON line that does not decode into a row.
+func jsonRowError(err error) rowError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		param := "string"
+		if typeErr.Type.Kind() == reflect.Float64 {
+			param = "number"
+		}
+		return rowError{field: typeErr.Field, rule: "type", param: param, key: "Must be a {0}"}
+	}
+	if field, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		return rowError{field: strings.TrimSuffix(field, `"`), rule: "unknown", key: "Not a known field"}
+	}
+	return rowError{rule: "json", param: err.Error(), key: "Invalid JSON: {0}"}
+}
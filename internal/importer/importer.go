@@ -0,0 +1,341 @@
+// Package importer loads supplier feeds (CSV or NDJSON) into the product
+// catalog. Rows are read one at a time and written in batches, so a feed of
+// any size runs in constant memory apart from its error report.
+package importer
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+)
+
+// DefaultBatchSize is the number of rows written per transaction.
+const DefaultBatchSize = 100
+
+// Options tune one import.
+type Options struct {
+	Format Format
+	// CreateMissing creates the categories and brands that no existing
+	// record matches by name, instead of failing their rows.
+	CreateMissing bool
+	BatchSize     int           // 0 means DefaultBatchSize
+	Translator    ut.Translator // language of the report; nil means English
+}
+
+// Importer creates products from feeds through the repositories.
+type Importer struct {
+	products   repository.ProductRepository
+	categories repository.CategoryRepository
+	brands     repository.BrandRepository
+}
+
+// New returns an Importer writing through the given repositories.
+func New(products repository.ProductRepository, categories repository.CategoryRepository, brands repository.BrandRepository) *Importer {
+	return &Importer{products: products, categories: categories, brands: brands}
+}
+
+// Import reads a feed from r and creates a product for each valid row, in
+// transactions of opts.BatchSize rows. Invalid rows are skipped and listed in
+// the report. The error is a *FeedError if the feed cannot be parsed at all;
+// any other error comes from reading r or from the database, and the batches
+// written before it stay written.
+func (im *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*models.ImportReport, error) {
+	source, err := newFeed(opts.Format, r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.Translator == nil {
+		opts.Translator = i18n.Fallback()
+	}
+
+	run := &run{
+		Importer: im,
+		opts:     opts,
+		report:   &models.ImportReport{},
+		categories: &names{
+			column:  "category",
+			ids:     map[string]uint{},
+			missing: "No category is named {0}",
+			invalid: "Cannot create category {0}: it needs a title of 2 to 100 characters and a category_cover_image URL",
+			lookup:  im.lookupCategories,
+			create:  im.createCategory,
+		},
+		brands: &names{
+			column:  "brand",
+			ids:     map[string]uint{},
+			missing: "No brand is named {0}",
+			invalid: "Cannot create brand {0}: it needs a name of 2 to 100 characters and a brand_cover_image URL",
+			lookup:  im.lookupBrands,
+			create:  im.createBrand,
+		},
+	}
+
+	for {
+		line, row, errs, err := source.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return run.report, err
+		}
+
+		run.report.Rows++
+		if err := run.add(ctx, line, row, errs); err != nil {
+			return run.report, err
+		}
+	}
+	if err := run.flush(ctx); err != nil {
+		return run.report, err
+	}
+
+	// Rows failing name resolution are reported when their batch is written
+	slices.SortStableFunc(run.report.Errors, func(a, b models.ImportRowError) int { return a.Row - b.Row })
+	run.report.CreatedCategories = run.categories.created
+	run.report.CreatedBrands = run.brands.created
+	return run.report, nil
+}
+
+// run is the state of one import.
+type run struct {
+	*Importer
+	opts       Options
+	report     *models.ImportReport
+	categories *names
+	brands     *names
+	pending    []pendingRow
+}
+
+// pendingRow is a valid row waiting for its batch to be written.
+type pendingRow struct {
+	line    int
+	row     row
+	product models.Product
+	errs    []models.FieldError
+}
+
+// add validates a row and queues it, writing the batch once it is full.
+func (r *run) add(ctx context.Context, line int, row row, errs []rowError) error {
+	fields := r.translate(errs)
+	if len(fields) == 0 {
+		product := models.Product{
+			Name:        row.Name,
+			Description: row.Description,
+			Price:       row.Price,
+			CoverImage:  row.CoverImage,
+		}
+		// category_id and brand_id are resolved from the names later
+		if err := respond.Validator().StructExcept(product, "CategoryID", "BrandID"); err != nil {
+			validationErrs, ok := respond.TranslateFieldErrors(r.opts.Translator, err)
+			if !ok {
+				return err
+			}
+			fields = append(fields, validationErrs...)
+		}
+		if row.Category == "" {
+			fields = append(fields, r.field("category", "required", "", "category is required"))
+		}
+		if row.Brand == "" {
+			fields = append(fields, r.field("brand", "required", "", "brand is required"))
+		}
+		if len(fields) == 0 {
+			r.pending = append(r.pending, pendingRow{line: line, row: row, product: product})
+		}
+	}
+	if len(fields) > 0 {
+		r.fail(line, fields)
+	}
+
+	if len(r.pending) >= r.opts.BatchSize {
+		return r.flush(ctx)
+	}
+	return nil
+}
+
+// flush resolves the names of the queued rows and creates their products in
+// one transaction.
+func (r *run) flush(ctx context.Context) error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	rows := r.pending
+	r.pending = nil
+
+	err := r.resolve(ctx, r.categories, rows,
+		func(p *pendingRow) (string, string) { return p.row.Category, p.row.CategoryCoverImage },
+		func(p *pendingRow, id uint) { p.product.CategoryID = id })
+	if err != nil {
+		return err
+	}
+	err = r.resolve(ctx, r.brands, rows,
+		func(p *pendingRow) (string, string) { return p.row.Brand, p.row.BrandCoverImage },
+		func(p *pendingRow, id uint) { p.product.BrandID = id })
+	if err != nil {
+		return err
+	}
+
+	products := make([]models.Product, 0, len(rows))
+	for _, p := range rows {
+		if len(p.errs) > 0 {
+			r.fail(p.line, p.errs)
+			continue
+		}
+		products = append(products, p.product)
+	}
+	if len(products) == 0 {
+		return nil
+	}
+	if err := r.products.CreateMany(ctx, products); err != nil {
+		return err
+	}
+	r.report.Created += len(products)
+	return nil
+}
+
+// errInvalidRecord is returned by names.create when the feed lacks what a
+// new category or brand needs.
+var errInvalidRecord = errors.New("invalid record")
+
+// names resolves category or brand names, case-insensitively, to IDs. The
+// first record by ID wins when several share a name.
+type names struct {
+	column  string
+	ids     map[string]uint // lower-cased name → ID, 0 if there is none
+	created []string
+	missing string // translation keys, "{0}" being the name
+	invalid string
+	lookup  func(ctx context.Context, names []string) (map[string]uint, error)
+	create  func(ctx context.Context, name, coverImage string) (uint, error)
+}
+
+// resolve sets the category or brand ID of each row through n, looking up
+// every name it has not seen yet in one query.
+func (r *run) resolve(ctx context.Context, n *names, rows []pendingRow, get func(*pendingRow) (name, coverImage string), set func(*pendingRow, uint)) error {
+	var unknown []string
+	for i := range rows {
+		name, _ := get(&rows[i])
+		if _, ok := n.ids[strings.ToLower(name)]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		found, err := n.lookup(ctx, unknown)
+		if err != nil {
+			return err
+		}
+		// Names not found are cached as 0 so later batches do not ask again
+		for _, name := range unknown {
+			n.ids[strings.ToLower(name)] = found[strings.ToLower(name)]
+		}
+	}
+
+	for i := range rows {
+		name, coverImage := get(&rows[i])
+		key := strings.ToLower(name)
+		id := n.ids[key]
+		if id == 0 && r.opts.CreateMissing {
+			var err error
+			id, err = n.create(ctx, name, coverImage)
+			switch {
+			case errors.Is(err, errInvalidRecord):
+				rows[i].errs = append(rows[i].errs, r.field(n.column, "create", name, n.invalid))
+				continue
+			case err != nil:
+				return err
+			}
+			n.ids[key] = id
+			n.created = append(n.created, name)
+		}
+		if id == 0 {
+			rows[i].errs = append(rows[i].errs, r.field(n.column, "exists", name, n.missing))
+			continue
+		}
+		set(&rows[i], id)
+	}
+	return nil
+}
+
+func (im *Importer) lookupCategories(ctx context.Context, titles []string) (map[string]uint, error) {
+	categories, err := im.categories.FindByTitles(ctx, titles)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(categories))
+	for _, category := range categories {
+		if key := strings.ToLower(category.Title); ids[key] == 0 {
+			ids[key] = category.ID
+		}
+	}
+	return ids, nil
+}
+
+func (im *Importer) lookupBrands(ctx context.Context, names []string) (map[string]uint, error) {
+	brands, err := im.brands.FindByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(brands))
+	for _, brand := range brands {
+		if key := strings.ToLower(brand.Name); ids[key] == 0 {
+			ids[key] = brand.ID
+		}
+	}
+	return ids, nil
+}
+
+func (im *Importer) createCategory(ctx context.Context, title, coverImage string) (uint, error) {
+	category := models.Category{Title: title, CoverImage: coverImage}
+	if err := respond.Validator().Struct(category); err != nil {
+		return 0, errInvalidRecord
+	}
+	if err := im.categories.Create(ctx, &category); err != nil {
+		return 0, err
+	}
+	return category.ID, nil
+}
+
+func (im *Importer) createBrand(ctx context.Context, name, coverImage string) (uint, error) {
+	brand := models.Brand{Name: name, CoverImage: coverImage}
+	if err := respond.Validator().Struct(brand); err != nil {
+		return 0, errInvalidRecord
+	}
+	if err := im.brands.Create(ctx, &brand); err != nil {
+		return 0, err
+	}
+	return brand.ID, nil
+}
+
+// fail adds a row to the report's errors.
+func (r *run) fail(line int, fields []models.FieldError) {
+	r.report.Failed++
+	r.report.Errors = append(r.report.Errors, models.ImportRowError{Row: line, Errors: fields})
+}
+
+// field returns a field error with its message translated; key's "{0}" is
+// filled with param.
+func (r *run) field(field, rule, param, key string) models.FieldError {
+	return models.FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: i18n.T(r.opts.Translator, key, param),
+	}
+}
+
+func (r *run) translate(errs []rowError) []models.FieldError {
+	var fields []models.FieldError
+	for _, e := range errs {
+		fields = append(fields, r.field(e.field, e.rule, e.param, e.key))
+	}
+	return fields
+}
@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func newImporter(t *testing.T) (*Importer, repository.ProductRepository) {
+	t.Helper()
+	categories := repository.NewMemoryCategoryRepository()
+	brands := repository.NewMemoryBrandRepository()
+	if err := categories.Create(context.Background(), &models.Category{Title: "Phones", CoverImage: "https://example.com/phones.png"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := brands.Create(context.Background(), &models.Brand{Name: "Acme", CoverImage: "https://example.com/acme.png"}); err != nil {
+		t.Fatal(err)
+	}
+	products := repository.NewMemoryProductRepository(categories, brands)
+	return New(products, categories, brands), products
+}
+
+// rowRules flattens a report's errors to "row field:rule" strings.
+func rowRules(report *models.ImportReport) []string {
+	var rules []string
+	for _, row := range report.Errors {
+		for _, field := range row.Errors {
+			rules = append(rules, fmt.Sprintf("%d %s:%s", row.Row, field.Field, field.Rule))
+		}
+	}
+	return rules
+}
+
+func TestImport(t *testing.T) {
+	feeds := map[Format]string{
+		CSV: "\ufeffName,description,price,cover_image,category,brand\n" +
+			"Phone X,A phone,499.5,https://example.com/x.png,Phones,Acme\n" +
+			"Phone Y,A phone,cheap,https://example.com/y.png,Phones,Acme\n" +
+			"Phone Z,A phone,99,https://example.com/z.png,Tablets,Acme\n" +
+			`"Phone ""W""",A phone,10,https://example.com/w.png,Phones,Acme` + "\n",
+		NDJSON: `{"name":"Phone X","description":"A phone","price":499.5,"cover_image":"https://example.com/x.png","category":"Phones","brand":"Acme"}` + "\n" +
+			`{"name":"Phone Y","description":"A phone","price":"cheap","cover_image":"https://example.com/y.png","category":"Phones","brand":"Acme"}` + "\n" +
+			`{"name":"Phone Z","description":"A phone","price":99,"cover_image":"https://example.com/z.png","category":"Tablets","brand":"Acme"}` + "\n" +
+			"\n" +
+			`{"name":"Phone \"W\"","description":"A phone","price":10,"cover_image":"https://example.com/w.png","category":"Phones","brand":"Acme"}` + "\n",
+	}
+	wantRules := map[Format]string{
+		CSV:    "[3 price:type 4 category:exists]",
+		NDJSON: "[2 price:type 3 category:exists]",
+	}
+
+	for format, feed := range feeds {
+		t.Run(string(format), func(t *testing.T) {
+			im, products := newImporter(t)
+			report, err := im.Import(context.Background(), strings.NewReader(feed), Options{Format: format, BatchSize: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Rows != 4 || report.Created != 2 || report.Failed != 2 {
+				t.Fatalf("report = %+v, want 4 rows, 2 created, 2 failed", report)
+			}
+			if got := fmt.Sprint(rowRules(report)); got != wantRules[format] {
+				t.Fatalf("row errors = %s, want %s", got, wantRules[format])
+			}
+
+			stored, total, err := products.List(context.Background(), repository.ProductQuery{Page: repository.Page{Limit: 10}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 2 || stored[0].Name != "Phone X" || stored[1].Name != `Phone "W"` || stored[1].BrandID == 0 {
+				t.Fatalf("stored products = %+v", stored)
+			}
+		})
+	}
+}
+
+func TestImportCreateMissing(t *testing.T) {
+	im, _ := newImporter(t)
+	feed := "name,description,price,cover_image,category,brand,category_cover_image\n" +
+		"Tab,A tablet,199,https://example.com/tab.png,Tablets,Acme,https://example.com/tablets.png\n" +
+		"Tab 2,A tablet,299,https://example.com/tab2.png,Tablets,Acme,\n" +
+		"Watch,A watch,99,https://example.com/watch.png,Watches,Acme,\n"
+
+	report, err := im.Import(context.Background(), strings.NewReader(feed), Options{Format: CSV, CreateMissing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Watches has no cover image, so it cannot be created
+	if report.Created != 2 || fmt.Sprint(report.CreatedCategories) != "[Tablets]" || len(report.CreatedBrands) != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	if got := fmt.Sprint(rowRules(report)); got != "[4 category:create]" {
+		t.Fatalf("row errors = %s", got)
+	}
+}
+
+func TestImportFeedErrors(t *testing.T) {
+	im, _ := newImporter(t)
+	tests := []struct {
+		format Format
+		feed   string
+		key    string
+	}{
+		{CSV, "", "Line {0}: the feed is empty"},
+		{CSV, "name,description,price,cover_image,category\n", "Line {0}: missing column {1}"},
+		{CSV, "name,name,description,price,cover_image,category,brand\n", "Line {0}: column {1} appears twice"},
+		{CSV, "name,colour\n", "Line {0}: unknown column {1}"},
+		{NDJSON, strings.Repeat("x", maxLineSize+1), "Line {0} is longer than {1} bytes"},
+	}
+	for _, tt := range tests {
+		_, err := im.Import(context.Background(), strings.NewReader(tt.feed), Options{Format: tt.format})
+		var feedErr *FeedError
+		if !errors.As(err, &feedErr) || feedErr.Key != tt.key {
+			t.Errorf("%s feed %.40q: err = %v, want %q", tt.format, tt.feed, err, tt.key)
+		}
+	}
+}
+
+func TestImportTranslatesReport(t *testing.T) {
+	im, _ := newImporter(t)
+	feed := `{"name":"Phone","description":"A phone","price":1,"cover_image":"https://example.com/p.png","category":"Phones","brand":"Nobody"}` + "\n"
+	report, err := im.Import(context.Background(), strings.NewReader(feed), Options{Format: NDJSON, Translator: i18n.Match("fr")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := i18n.T(i18n.Match("fr"), "No brand is named {0}", "Nobody")
+	if len(report.Errors) != 1 || report.Errors[0].Errors[0].Message != want || strings.HasPrefix(want, "No brand") {
+		t.Fatalf("errors = %+v, want %q", report.Errors, want)
+	}
+}
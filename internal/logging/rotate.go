@@ -0,0 +1,366 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat stamps rotated files: server.log → server-20250101T120000.000.log.
+// Files rotated within the same millisecond get a counter that keeps names
+// sorting by time: server-20250101T120000.000_1.log.
+const rotatedTimeFormat = "20060102T150405.000"
+
+// RotateOptions configures a RotatingFile. Zero MaxSize, Interval, MaxAge or
+// MaxFiles disable that rule.
+type RotateOptions struct {
+	Path     string        // active log file, e.g. logs/server.log
+	Mode     os.FileMode   // permissions of the active and rotated files
+	MaxSize  int64         // rotate before a write would push the file past this many bytes
+	Interval time.Duration // rotate on Interval boundaries (24h: at midnight UTC)
+	MaxAge   time.Duration // delete rotated files older than this
+	MaxFiles int           // keep at most this many rotated files
+	Compress bool          // gzip rotated files
+}
+
+// RotatingFile is an io.Writer over a log file that rotates by size and time,
+// optionally gzips rotated files and prunes them by age and count. Reopen
+// supports external rotation (logrotate with a SIGHUP postrotate). It is safe
+// for concurrent use.
+type RotatingFile struct {
+	opts RotateOptions
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	// Compression and pruning run in one background goroutine so writes never
+	// wait on them.
+	millCh   chan struct{}
+	millDone chan struct{}
+}
+
+// OpenRotatingFile creates the log directory if needed and opens (or
+// continues) the file at opts.Path. A file last written before the current
+// rotation interval is rotated straight away.
+func OpenRotatingFile(opts RotateOptions) (*RotatingFile, error) {
+	if opts.Mode == 0 {
+		opts.Mode = 0o640
+	}
+	// Directories get execute wherever the file mode grants read
+	dirMode := opts.Mode | (opts.Mode&0o444)>>2
+	if err := os.MkdirAll(filepath.Dir(opts.Path), dirMode); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+
+	r := &RotatingFile{
+		opts:     opts,
+		millCh:   make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	go r.mill()
+
+	if info, err := r.file.Stat(); err == nil && r.size > 0 && opts.Interval > 0 &&
+		info.ModTime().Before(time.Now().Truncate(opts.Interval)) {
+		if err := r.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+	r.wake()
+	return r, nil
+}
+
+// Write appends p, rotating first if the size limit or interval boundary
+// has been reached.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	sizeExceeded := r.opts.MaxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.opts.MaxSize
+	intervalPassed := r.opts.Interval > 0 && !time.Now().Before(r.nextRotation)
+	if sizeExceeded || (intervalPassed && r.size > 0) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	} else if intervalPassed {
+		r.nextRotation = nextBoundary(time.Now(), r.opts.Interval)
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate moves the current file aside and starts a new one.
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+// Reopen reopens opts.Path without rotating. Call it after an external tool
+// has renamed the file, typically on SIGHUP.
+func (r *RotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := r.file
+	if old == nil {
+		return os.ErrClosed
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return old.Close()
+}
+
+// Sync flushes the current file to disk.
+func (r *RotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Sync()
+}
+
+// Close syncs and closes the file and waits for pending compression and
+// pruning to finish.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	file := r.file
+	r.file = nil
+	r.mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	close(r.millCh)
+	<-r.millDone
+	return errors.Join(file.Sync(), file.Close())
+}
+
+// open opens opts.Path for appending. r.mu must be held.
+func (r *RotatingFile) open() error {
+	file, err := os.OpenFile(r.opts.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, r.opts.Mode)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	// OpenFile's mode is filtered by the umask; enforce the configured one
+	if info.Mode().Perm() != r.opts.Mode.Perm() {
+		if err := file.Chmod(r.opts.Mode); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("chmod log file: %w", err)
+		}
+	}
+
+	r.file = file
+	r.size = info.Size()
+	if r.opts.Interval > 0 {
+		r.nextRotation = nextBoundary(time.Now(), r.opts.Interval)
+	}
+	return nil
+}
+
+// rotate renames the current file with a timestamp, opens a fresh one and
+// wakes the mill. r.mu must be held.
+func (r *RotatingFile) rotate() error {
+	if r.file == nil {
+		return os.ErrClosed
+	}
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(r.opts.Path, r.rotatedName(time.Now())); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.wake()
+	return nil
+}
+
+// wake asks the mill to compress and prune, unless a run is already queued.
+func (r *RotatingFile) wake() {
+	select {
+	case r.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// rotatedName returns the name a file rotated at t is given: the first one
+// that is free, compressed or not, so an earlier rotation within the same
+// millisecond is never overwritten.
+func (r *RotatingFile) rotatedName(t time.Time) string {
+	dir, base := filepath.Split(r.opts.Path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext) + "-" + t.UTC().Format(rotatedTimeFormat)
+	for seq := 0; ; seq++ {
+		name := stem
+		if seq > 0 {
+			name += "_" + strconv.Itoa(seq)
+		}
+		path := filepath.Join(dir, name+ext)
+		if !exists(path) && !exists(path+".gz") {
+			return path
+		}
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// mill compresses and prunes rotated files each time it is woken.
+func (r *RotatingFile) mill() {
+	defer close(r.millDone)
+	for range r.millCh {
+		if err := r.compressAndPrune(); err != nil {
+			// Writing through slog here could deadlock on our own lock
+			fmt.Fprintf(os.Stderr, "log rotation: %v\n", err)
+		}
+	}
+}
+
+// rotatedFile is a rotated log file found on disk.
+type rotatedFile struct {
+	path       string
+	rotatedAt  time.Time
+	seq        int // counter of a name taken within the same millisecond
+	compressed bool
+}
+
+func (r *RotatingFile) compressAndPrune() error {
+	files, err := r.rotatedFiles()
+	if err != nil {
+		return err
+	}
+
+	// Newest first; everything past MaxFiles or older than MaxAge goes
+	var errs []error
+	now := time.Now()
+	kept := files[:0]
+	for i, f := range files {
+		tooMany := r.opts.MaxFiles > 0 && i >= r.opts.MaxFiles
+		tooOld := r.opts.MaxAge > 0 && now.Sub(f.rotatedAt) > r.opts.MaxAge
+		if tooMany || tooOld {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	if r.opts.Compress {
+		for _, f := range kept {
+			if !f.compressed {
+				if err := compressFile(f.path, r.opts.Mode); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// rotatedFiles lists rotated files next to opts.Path, newest first.
+func (r *RotatingFile) rotatedFiles() ([]rotatedFile, error) {
+	dir, base := filepath.Split(r.opts.Path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []rotatedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimPrefix(name, prefix)
+		compressed := strings.HasSuffix(stamp, ext+".gz")
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		stamp, counter, hasCounter := strings.Cut(stamp, "_")
+
+		rotatedAt, err := time.Parse(rotatedTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if hasCounter {
+			if seq, err = strconv.Atoi(counter); err != nil || seq < 1 {
+				continue
+			}
+		}
+		files = append(files, rotatedFile{path: filepath.Join(dir, name), rotatedAt: rotatedAt, seq: seq, compressed: compressed})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].rotatedAt.Equal(files[j].rotatedAt) {
+			return files[i].rotatedAt.After(files[j].rotatedAt)
+		}
+		return files[i].seq > files[j].seq
+	})
+	return files, nil
+}
+
+// compressFile gzips path to path.gz and removes the original. A partial .gz
+// left by a crash is overwritten on the next attempt.
+func compressFile(path string, mode os.FileMode) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := errors.Join(gz.Close(), dst.Close()); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// nextBoundary returns the first multiple of interval after t.
+func nextBoundary(t time.Time, interval time.Duration) time.Time {
+	return t.Truncate(interval).Add(interval)
+}
@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readLog returns the content of a rotated file, gunzipping it if needed.
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRotatingFileBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	file, err := OpenRotatingFile(RotateOptions{Path: path, Mode: 0o600, MaxSize: 10, MaxFiles: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		// Rotated names carry the time to the millisecond
+		time.Sleep(2 * time.Millisecond)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readLog(t, path); got != "fourth\n" {
+		t.Fatalf("active file = %q, want the last line", got)
+	}
+	rotated, err := filepath.Glob(filepath.Join(dir, "server-*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("rotated files = %v, want the 2 newest", rotated)
+	}
+	// Names sort by time; the oldest line was pruned
+	if got := readLog(t, rotated[0]) + readLog(t, rotated[1]); got != "second\nthird\n" {
+		t.Fatalf("rotated content = %q", got)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "server-*.log")); len(leftovers) != 0 {
+		t.Fatalf("uncompressed rotated files left: %v", leftovers)
+	}
+	if info, err := os.Stat(rotated[0]); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("rotated file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	file, err := OpenRotatingFile(RotateOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write([]byte("before\n")); err != nil {
+		t.Fatal(err)
+	}
+	// What logrotate does before sending SIGHUP
+	moved := filepath.Join(dir, "server.log.1")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.Write([]byte("after\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readLog(t, moved); got != "before\n" {
+		t.Fatalf("moved file = %q", got)
+	}
+	if got := readLog(t, path); got != "after\n" {
+		t.Fatalf("reopened file = %q", got)
+	}
+}
+
+func TestRotatingFileSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.log")
+	// Without compression or pruning the mill leaves rotated files alone
+	file, err := OpenRotatingFile(RotateOptions{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A name taken by a rotated file, compressed or not, gets a counter
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	first := file.rotatedName(at)
+	if want := filepath.Join(dir, "server-20250101T120000.000.log"); first != want {
+		t.Fatalf("rotatedName = %q, want %q", first, want)
+	}
+	if err := os.WriteFile(first, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	second := file.rotatedName(at)
+	if want := filepath.Join(dir, "server-20250101T120000.000_1.log"); second != want {
+		t.Fatalf("rotatedName = %q, want %q", second, want)
+	}
+	if err := os.WriteFile(second+".gz", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := file.rotatedName(at), filepath.Join(dir, "server-20250101T120000.000_2.log"); got != want {
+		t.Fatalf("rotatedName = %q, want %q", got, want)
+	}
+	files, err := file.rotatedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].path != second+".gz" || files[1].path != first {
+		t.Fatalf("rotated files = %+v, want the counted one first", files)
+	}
+	for _, name := range []string{first, second + ".gz"} {
+		if err := os.Remove(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Back-to-back rotations keep every file
+	lines := []string{"one\n", "two\n", "three\n", "four\n"}
+	for _, line := range lines {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := filepath.Glob(filepath.Join(dir, "server-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	for _, name := range rotated {
+		got += readLog(t, name)
+	}
+	if want := strings.Join(lines, ""); got != want {
+		t.Fatalf("rotated content in name order = %q, want %q", got, want)
+	}
+}
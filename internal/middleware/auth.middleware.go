@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/apikeys"
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockSkew is the leeway allowed when checking exp/nbf/iat.
+const clockSkew = 30 * time.Second
+
+// touchInterval throttles last_used_at writes for busy API keys.
+const touchInterval = time.Minute
+
+// principalKey is the fiber.Ctx Locals key holding the authenticated *Principal.
+const principalKey = "auth.principal"
+
+// HeaderAPIKey carries partner API keys.
+const HeaderAPIKey = "X-API-Key"
+
+// Claims are the JWT claims understood by the API.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Principal is the authenticated caller of a request: either a user holding a
+// JWT (Roles) or a partner holding an API key (Scopes). Both are checked by RBAC.
+type Principal struct {
+	Subject  string   // JWT "sub", or "apikey:<id>"
+	Roles    []string // roles from the JWT, resolved through the role policy
+	Scopes   []string // permissions granted directly by an API key
+	APIKeyID uint     // zero for JWT callers
+	Claims   *Claims  // nil for API key callers
+}
+
+// PrincipalFrom returns the caller authenticated by Authenticate for this request.
+func PrincipalFrom(c *fiber.Ctx) (*Principal, bool) {
+	principal, ok := c.Locals(principalKey).(*Principal)
+	return principal, ok
+}
+
+// ClaimsFrom returns the JWT claims verified for this request, if the caller used a JWT.
+func ClaimsFrom(c *fiber.Ctx) (*Claims, bool) {
+	principal, ok := PrincipalFrom(c)
+	if !ok || principal.Claims == nil {
+		return nil, false
+	}
+	return principal.Claims, true
+}
+
+// authError is a credential failure that maps to a 401 response.
+type authError struct {
+	code    string // RFC 6750 error code, may be empty
+	message string
+}
+
+func (e *authError) Error() string { return e.message }
+
+var errNoCredentials = &authError{message: "Missing bearer token or API key"}
+
+// authenticator verifies bearer JWTs and API keys.
+type authenticator struct {
+	parser  *jwt.Parser
+	key     interface{}
+	apiKeys repository.APIKeyRepository
+}
+
+func newAuthenticator(cfg *config.App, apiKeys repository.APIKeyRepository) *authenticator {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{cfg.JWTAlgorithm}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	return &authenticator{parser: jwt.NewParser(opts...), key: cfg.JWTKey, apiKeys: apiKeys}
+}
+
+// authenticate resolves the request's credentials to a principal. Credential
+// problems are returned as *authError; any other error is a storage failure.
+func (a *authenticator) authenticate(c *fiber.Ctx) (*Principal, error) {
+	if key := c.Get(HeaderAPIKey); key != "" {
+		principal, err := authenticateAPIKey(c.UserContext(), a.apiKeys, key)
+		if err != nil {
+			return nil, err
+		}
+		if principal == nil {
+			return nil, &authError{message: "Invalid API key"}
+		}
+		return principal, nil
+	}
+
+	raw, ok := bearerToken(c)
+	if !ok {
+		return nil, errNoCredentials
+	}
+
+	claims := &Claims{}
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		return a.key, nil
+	}
+	if _, err := a.parser.ParseWithClaims(raw, claims, keyFunc); err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, &authError{code: "invalid_token", message: "Token expired"}
+		}
+		return nil, &authError{code: "invalid_token", message: "Invalid token"}
+	}
+
+	return &Principal{Subject: claims.Subject, Roles: claims.Roles, Claims: claims}, nil
+}
+
+// Authenticate returns a middleware that requires either an
+// "Authorization: Bearer" JWT signed with the algorithm and key from cfg, or a
+// valid X-API-Key. Only the configured JWT algorithm is accepted, so an RS256
+// public key can never be abused as an HS256 secret. Requests already
+// identified by Identify pass straight through. When auth is disabled the
+// middleware lets every request through.
+//
+// What a route behind it returns depends on who asked, so its responses are
+// marked "Cache-Control: private, no-store", which CacheControl leaves alone.
+func Authenticate(cfg *config.App, apiKeys repository.APIKeyRepository) fiber.Handler {
+	if !cfg.AuthEnabled {
+		return func(c *fiber.Ctx) error {
+			c.Set(fiber.HeaderCacheControl, cacheControlPrivate)
+			return c.Next()
+		}
+	}
+
+	auth := newAuthenticator(cfg, apiKeys)
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, cacheControlPrivate)
+		if _, ok := PrincipalFrom(c); ok {
+			return c.Next()
+		}
+
+		principal, err := auth.authenticate(c)
+		if err != nil {
+			var authErr *authError
+			if errors.As(err, &authErr) {
+				return unauthorized(c, authErr.code, authErr.message)
+			}
+			return respond.ServerError(c, "Failed to verify API key", err)
+		}
+
+		c.Locals(principalKey, principal)
+		return c.Next()
+	}
+}
+
+// Identify returns a middleware that attaches the caller's principal when the
+// request carries valid credentials, and otherwise lets it through anonymously.
+// It lets public routes (and the rate limiter) tell callers apart; Authenticate
+// still decides whether a route requires credentials.
+func Identify(cfg *config.App, apiKeys repository.APIKeyRepository) fiber.Handler {
+	if !cfg.AuthEnabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	auth := newAuthenticator(cfg, apiKeys)
+	return func(c *fiber.Ctx) error {
+		principal, err := auth.authenticate(c)
+		if err == nil {
+			c.Locals(principalKey, principal)
+		} else if !errors.As(err, new(*authError)) {
+			slog.WarnContext(c.UserContext(), "Failed to identify caller", "error", err)
+		}
+		return c.Next()
+	}
+}
+
+// authenticateAPIKey resolves a presented key to a principal. Unknown, revoked
+// and expired keys yield a nil principal; errors are storage failures.
+func authenticateAPIKey(ctx context.Context, apiKeys repository.APIKeyRepository, key string) (*Principal, error) {
+	prefix, ok := apikeys.Prefix(key)
+	if !ok {
+		return nil, nil
+	}
+
+	stored, err := apiKeys.FindByPrefix(ctx, prefix)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !apikeys.Verify(key, stored.Hash) || !stored.Active(now) {
+		return nil, nil
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) > touchInterval {
+		if err := apiKeys.Touch(ctx, stored.ID, now); err != nil {
+			slog.WarnContext(ctx, "Failed to record API key usage", "api_key_id", stored.ID, "error", err)
+		}
+	}
+
+	return &Principal{
+		Subject:  "apikey:" + strconv.FormatUint(uint64(stored.ID), 10),
+		Scopes:   stored.Scopes,
+		APIKeyID: stored.ID,
+	}, nil
+}
+
+// bearerToken extracts the token from the Authorization header.
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	scheme, token, ok := strings.Cut(c.Get(fiber.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// unauthorized writes a 401 in the standard envelope with an RFC 6750 challenge.
+func unauthorized(c *fiber.Ctx, errorCode, message string) error {
+	challenge := "Bearer"
+	if errorCode != "" {
+		challenge += ` error="` + errorCode + `"`
+	}
+	c.Set(fiber.HeaderWWWAuthenticate, challenge)
+
+	return respond.Error(c, fiber.StatusUnauthorized, message)
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/apikeys"
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func signToken(t *testing.T, method jwt.SigningMethod, claims Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// createAPIKey stores key with the products:write scope in repo.
+func createAPIKey(t *testing.T, repo repository.APIKeyRepository, key string, expiresAt *time.Time) string {
+	t.Helper()
+	prefix, ok := apikeys.Prefix(key)
+	if !ok {
+		t.Fatalf("malformed test key %q", key)
+	}
+	stored := models.APIKey{Name: "Partner", Prefix: prefix, Hash: apikeys.Hash(key), Scopes: []string{"products:write"}, ExpiresAt: expiresAt}
+	if err := repo.Create(context.Background(), &stored); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := repository.NewMemoryAPIKeyRepository()
+	cfg := &config.App{AuthEnabled: true, JWTAlgorithm: "HS256", JWTKey: testSecret, JWTIssuer: "catalog"}
+	app := fiber.New()
+	app.Use(Authenticate(cfg, keys))
+	app.Get("/", func(c *fiber.Ctx) error {
+		principal, _ := PrincipalFrom(c)
+		return c.SendString(principal.Subject)
+	})
+
+	partnerKey := createAPIKey(t, keys, "sk_0a1b2c3d_c2VjcmV0LXNlY3JldC1zZWNyZXQ", nil)
+	past := time.Now().Add(-time.Hour)
+	expiredKey := createAPIKey(t, keys, "sk_4e5f6a7b_b2xkLXNlY3JldC1vbGQtc2VjcmV0", &past)
+
+	valid := Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		Issuer:    "catalog",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}}
+	expired := valid
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+	otherIssuer := valid
+	otherIssuer.Issuer = "elsewhere"
+	noExpiry := valid
+	noExpiry.ExpiresAt = nil
+
+	tests := []struct {
+		name          string
+		authorization string
+		apiKey        string
+		status        int
+		subject       string
+	}{
+		{"valid", "Bearer " + signToken(t, jwt.SigningMethodHS256, valid), "", fiber.StatusOK, "alice"},
+		{"lowercase scheme", "bearer " + signToken(t, jwt.SigningMethodHS256, valid), "", fiber.StatusOK, "alice"},
+		{"missing", "", "", fiber.StatusUnauthorized, ""},
+		{"basic", "Basic YWxpY2U6c2VjcmV0", "", fiber.StatusUnauthorized, ""},
+		{"expired", "Bearer " + signToken(t, jwt.SigningMethodHS256, expired), "", fiber.StatusUnauthorized, ""},
+		{"no expiry", "Bearer " + signToken(t, jwt.SigningMethodHS256, noExpiry), "", fiber.StatusUnauthorized, ""},
+		{"other issuer", "Bearer " + signToken(t, jwt.SigningMethodHS256, otherIssuer), "", fiber.StatusUnauthorized, ""},
+		{"other algorithm", "Bearer " + signToken(t, jwt.SigningMethodHS512, valid), "", fiber.StatusUnauthorized, ""},
+		{"api key", "", partnerKey, fiber.StatusOK, "apikey:1"},
+		{"api key wins over a bad token", "Bearer nope", partnerKey, fiber.StatusOK, "apikey:1"},
+		{"expired api key", "", expiredKey, fiber.StatusUnauthorized, ""},
+		{"wrong secret", "", partnerKey[:len(partnerKey)-2] + "xx", fiber.StatusUnauthorized, ""},
+		{"malformed api key", "", "not-a-key", fiber.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			if tt.apiKey != "" {
+				req.Header.Set(HeaderAPIKey, tt.apiKey)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d; body: %s", resp.StatusCode, tt.status, body)
+			}
+			if tt.status == fiber.StatusOK && string(body) != tt.subject {
+				t.Fatalf("subject = %q, want %q", body, tt.subject)
+			}
+			if tt.status == fiber.StatusUnauthorized && !strings.HasPrefix(resp.Header.Get(fiber.HeaderWWWAuthenticate), "Bearer") {
+				t.Fatal("401 without a Bearer challenge")
+			}
+		})
+	}
+}
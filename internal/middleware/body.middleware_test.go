@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBodyLimits(t *testing.T) {
+	app := fiber.New(fiber.Config{BodyLimit: 16, StreamRequestBody: true})
+	streaming := func(c *fiber.Ctx) bool { return c.Path() == "/stream" }
+	app.Use(BufferBody(16, streaming))
+
+	app.Post("/buffer", func(c *fiber.Ctx) error {
+		return c.SendString(string(c.Body()))
+	})
+	app.Post("/stream", StreamBody(32), func(c *fiber.Ctx) error {
+		data, err := io.ReadAll(BodyStream(c))
+		if errors.Is(err, ErrBodyTooLarge) {
+			return c.SendStatus(fiber.StatusRequestEntityTooLarge)
+		}
+		if err != nil {
+			return err
+		}
+		return c.SendString(string(data))
+	})
+
+	tests := []struct {
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"/buffer", 16, false, fiber.StatusOK},
+		{"/buffer", 17, false, fiber.StatusRequestEntityTooLarge},
+		{"/buffer", 17, true, fiber.StatusRequestEntityTooLarge},
+		{"/stream", 32, false, fiber.StatusOK},
+		{"/stream", 32, true, fiber.StatusOK},
+		{"/stream", 33, false, fiber.StatusRequestEntityTooLarge},
+		{"/stream", 33, true, fiber.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		body := strings.Repeat("x", tt.size)
+		req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body))
+		if tt.chunked {
+			// No Content-Length: the limit is only found by reading
+			req.ContentLength = -1
+			req.TransferEncoding = []string{"chunked"}
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s with %d bytes (chunked %v): status %d, want %d", tt.path, tt.size, tt.chunked, resp.StatusCode, tt.want)
+		}
+		if tt.want == fiber.StatusOK && string(data) != body {
+			t.Errorf("%s with %d bytes (chunked %v): handler read %d bytes", tt.path, tt.size, tt.chunked, len(data))
+		}
+	}
+}
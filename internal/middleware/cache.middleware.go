@@ -0,0 +1,29 @@
+package middleware
+
+import "github.com/gofiber/fiber/v2"
+
+// cacheControlPrivate keeps shared caches from storing a response that is
+// only meant for its caller.
+const cacheControlPrivate = "private, no-store"
+
+// CacheControl returns a middleware that sets policy as the Cache-Control
+// header of successful (200 or 304) GET and HEAD responses in its route group.
+// Errors and writes are left alone, so they are never cached, and so is a
+// route that set its own Cache-Control, such as one behind Authenticate. An
+// empty policy disables it.
+func CacheControl(policy string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := c.Next()
+		if err != nil || policy == "" || (c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead) {
+			return err
+		}
+		if len(c.Response().Header.Peek(fiber.HeaderCacheControl)) > 0 {
+			return nil
+		}
+		switch c.Response().StatusCode() {
+		case fiber.StatusOK, fiber.StatusNotModified:
+			c.Set(fiber.HeaderCacheControl, policy)
+		}
+		return nil
+	}
+}
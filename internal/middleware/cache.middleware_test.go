@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestCacheControl(t *testing.T) {
+	const policy = "public, max-age=300"
+	token := signToken(t, jwt.SigningMethodHS256, Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		Issuer:    "catalog",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}})
+
+	for _, authEnabled := range []bool{true, false} {
+		cfg := &config.App{AuthEnabled: authEnabled, JWTAlgorithm: "HS256", JWTKey: testSecret, JWTIssuer: "catalog"}
+		app := fiber.New()
+		group := app.Group("/items", CacheControl(policy))
+		ok := func(c *fiber.Ctx) error { return c.SendString("ok") }
+		group.Get("/", ok)
+		group.Get("/missing", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNotFound) })
+		group.Get("/export", Authenticate(cfg, repository.NewMemoryAPIKeyRepository()), ok)
+		group.Post("/", ok)
+
+		tests := []struct {
+			name          string
+			method        string
+			path          string
+			authorization string
+			want          string
+		}{
+			{"public read", http.MethodGet, "/items", "", policy},
+			{"public head", http.MethodHead, "/items", "", policy},
+			{"error", http.MethodGet, "/items/missing", "", ""},
+			{"write", http.MethodPost, "/items", "", ""},
+			{"authenticated read", http.MethodGet, "/items/export", "Bearer " + token, "private, no-store"},
+			{"unauthenticated read", http.MethodGet, "/items/export", "", "private, no-store"},
+		}
+		for _, tt := range tests {
+			name := tt.name
+			if !authEnabled {
+				name += " without auth"
+			}
+			t.Run(name, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, tt.path, nil)
+				if tt.authorization != "" {
+					req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+				}
+				resp, err := app.Test(req, -1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := resp.Header.Get(fiber.HeaderCacheControl); got != tt.want {
+					t.Fatalf("Cache-Control = %q, want %q", got, tt.want)
+				}
+			})
+		}
+	}
+}
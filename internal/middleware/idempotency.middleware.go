@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/idempotency"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"strings"
+)
+
+// HeaderIdempotencyKey names the key a client sends to make a POST safe to retry.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// headerIdempotentReplayed marks responses served from the idempotency store.
+const headerIdempotentReplayed = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength matches the idempotency_key column.
+const maxIdempotencyKeyLength = 255
+
+// Idempotency returns a middleware that makes a POST with an Idempotency-Key
+// header run at most once per caller and key: the first successful (2xx)
+// response is stored for cfg.IdempotencyTTL and replayed verbatim to retries.
+// A retry while the first request is still running gets 409, and reusing a key
+// for a different method, path or body gets 422. Failed requests release the
+// key so they can be retried. Requests without the header pass straight
+// through. The body is hashed before the handler runs, so on StreamBody
+// routes it is read into memory and held to cfg.BodyLimit: a longer one gets
+// 413.
+//
+// Register it after authentication so keys are scoped to the caller and
+// rejected requests never claim one.
+func Idempotency(cfg *config.App, store *idempotency.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.Trim(c.Get(HeaderIdempotencyKey), `"`)
+		if key == "" {
+			return c.Next()
+		}
+		if !validIdempotencyKey(key) {
+			return respond.Error(c, fiber.StatusBadRequest, "Invalid Idempotency-Key header")
+		}
+
+		fingerprint, limit, err := requestFingerprint(c, cfg.BodyLimit)
+		if errors.Is(err, ErrBodyTooLarge) {
+			return bodyTooLarge(c, limit)
+		}
+		if err != nil {
+			return respond.Error(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+
+		ctx := c.UserContext()
+		scope := callerKey(c)
+		stored, err := store.Begin(ctx, scope, key, fingerprint, cfg.IdempotencyLockTimeout)
+		switch {
+		case errors.Is(err, idempotency.ErrInFlight):
+			return respond.Error(c, fiber.StatusConflict, "A request with this Idempotency-Key is still being processed")
+		case errors.Is(err, idempotency.ErrMismatch):
+			return respond.Problem(c, fiber.StatusUnprocessableEntity, respond.CodeIdempotencyKeyReused,
+				"This Idempotency-Key was already used for a different request")
+		case err != nil:
+			return respond.ServerError(c, "Failed to check Idempotency-Key", err)
+		case stored != nil:
+			c.Set(headerIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, stored.ContentType)
+			return c.Status(stored.StatusCode).SendString(stored.Body)
+		}
+
+		err = c.Next()
+		status := c.Response().StatusCode()
+		if err != nil || status < 200 || status > 299 {
+			if releaseErr := store.Release(ctx, scope, key); releaseErr != nil {
+				slog.WarnContext(ctx, "Failed to release idempotency key", "error", releaseErr)
+			}
+			return err
+		}
+
+		contentType := string(c.Response().Header.ContentType())
+		if err := store.Complete(ctx, scope, key, status, contentType, c.Response().Body(), cfg.IdempotencyTTL); err != nil {
+			// The write happened; a retry will get 409 until the lock times out
+			slog.WarnContext(ctx, "Failed to store idempotent response", "error", err)
+		}
+		return nil
+	}
+}
+
+// requestFingerprint hashes what makes two requests "the same": method, path
+// with query string, and body. A body streamed through StreamBody is read
+// into memory first, as the hash needs all of it before the handler runs, so
+// it is held to limit like any buffered body; the limit that applied comes
+// back with ErrBodyTooLarge.
+func requestFingerprint(c *fiber.Ctx, limit int64) (string, int64, error) {
+	body, limit, err := bufferBodyStream(c, limit)
+	if err != nil {
+		return "", limit, err
+	}
+	h := sha256.New()
+	h.Write([]byte(c.Method() + " " + c.OriginalURL() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), limit, nil
+}
+
+// validIdempotencyKey accepts up to maxIdempotencyKeyLength printable ASCII
+// characters without spaces, e.g. a UUID.
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/idempotency"
+	"Scalable-Secure-Go-Web/internal/migrations"
+	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newIdempotencyStore(t *testing.T) *idempotency.Store {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "idempotency.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return idempotency.NewStore(db)
+}
+
+func TestIdempotency(t *testing.T) {
+	cfg := &config.App{IdempotencyTTL: time.Hour, IdempotencyLockTimeout: time.Minute}
+	app := fiber.New()
+	app.Use(Idempotency(cfg, newIdempotencyStore(t)))
+
+	created, failures := 0, 1
+	app.Post("/items", func(c *fiber.Ctx) error {
+		if strings.Contains(string(c.Body()), "fail") && failures > 0 {
+			failures--
+			return c.SendStatus(fiber.StatusServiceUnavailable)
+		}
+		created++
+		return c.Status(fiber.StatusCreated).SendString("item " + strconv.Itoa(created))
+	})
+
+	post := func(key, body string) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/items", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(data)
+	}
+
+	resp, body := post("key-1", "a")
+	if resp.StatusCode != fiber.StatusCreated || body != "item 1" || resp.Header.Get(headerIdempotentReplayed) != "" {
+		t.Fatalf("first request: %d %q", resp.StatusCode, body)
+	}
+	resp, body = post("key-1", "a")
+	if resp.StatusCode != fiber.StatusCreated || body != "item 1" || resp.Header.Get(headerIdempotentReplayed) != "true" {
+		t.Fatalf("retry: %d %q, replayed %q", resp.StatusCode, body, resp.Header.Get(headerIdempotentReplayed))
+	}
+
+	if resp, _ = post("key-1", "b"); resp.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("key reused for another body: status %d", resp.StatusCode)
+	}
+	if resp, _ = post("bad key", "a"); resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("invalid key: status %d", resp.StatusCode)
+	}
+	if resp, body = post("", "a"); resp.StatusCode != fiber.StatusCreated || body != "item 2" {
+		t.Fatalf("without a key: %d %q", resp.StatusCode, body)
+	}
+
+	// A failed request releases its key for the retry
+	if resp, _ = post("key-2", "fail"); resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Fatalf("failing request: status %d", resp.StatusCode)
+	}
+	if resp, body = post("key-2", "fail"); resp.StatusCode != fiber.StatusCreated || body != "item 3" {
+		t.Fatalf("retry after a failure: %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestIdempotencyStreamedBody(t *testing.T) {
+	cfg := &config.App{BodyLimit: 16, IdempotencyTTL: time.Hour, IdempotencyLockTimeout: time.Minute}
+	app := fiber.New(fiber.Config{BodyLimit: 16, StreamRequestBody: true, ErrorHandler: respond.ErrorHandler(false)})
+	app.Use(Locale())
+	app.Post("/import", StreamBody(1024), Idempotency(cfg, newIdempotencyStore(t)), func(c *fiber.Ctx) error {
+		data, err := io.ReadAll(BodyStream(c))
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.Itoa(len(data)))
+	})
+
+	post := func(key string, size int) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/import", strings.NewReader(strings.Repeat("x", size)))
+		req.Header.Set(fiber.HeaderAcceptLanguage, "fr")
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, string(data)
+	}
+
+	// A keyed body is hashed in memory, so BODY_LIMIT applies to it
+	if resp, body := post("key-1", 16); resp.StatusCode != fiber.StatusOK || body != "16" {
+		t.Fatalf("keyed body at the limit: %d %q", resp.StatusCode, body)
+	}
+	resp, body := post("key-2", 17)
+	if resp.StatusCode != fiber.StatusRequestEntityTooLarge {
+		t.Fatalf("keyed body past the limit: status %d", resp.StatusCode)
+	}
+	var problem models.Problem
+	if err := json.Unmarshal([]byte(body), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if want := i18n.T(i18n.Match("fr"), "The request body must not be larger than {0} bytes", "16"); problem.Detail != want {
+		t.Fatalf("detail = %q, want %q", problem.Detail, want)
+	}
+
+	// Without a key the body streams up to the route's own limit
+	if resp, body := post("", 1000); resp.StatusCode != fiber.StatusOK || body != "1000" {
+		t.Fatalf("unkeyed body: %d %q", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locale returns a middleware that picks the response language from
+// Accept-Language and stores its translator in the UserContext for error
+// messages. The chosen locale is reported in Content-Language.
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		trans := i18n.Match(c.Get(fiber.HeaderAcceptLanguage))
+		c.SetUserContext(i18n.WithTranslator(c.UserContext(), trans))
+		c.Set(fiber.HeaderContentLanguage, trans.Locale())
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
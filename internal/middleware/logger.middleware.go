@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"log/slog"
+	"time"
+)
+
+// RequestLogger returns a middleware that writes one structured access log
+// record per request. Register it after RequestID and Tracing so the record
+// carries the request and trace IDs. 5xx responses are logged at error level,
+// everything else at info.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		route, status := routeStatus(c, err)
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.LogAttrs(c.UserContext(), level, "request",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+			bodySize(c),
+			slog.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		)
+		return err
+	}
+}
+
+// bodySize is the bytes attribute of the access log. A streamed body, such as
+// an export, is left out: reading it here would buffer the whole stream.
+func bodySize(c *fiber.Ctx) slog.Attr {
+	if c.Response().IsBodyStream() {
+		return slog.Attr{}
+	}
+	return slog.Int("bytes", len(c.Response().Body()))
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/metrics"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"time"
+)
+
+// Metrics returns a middleware that records request counts, latency and
+// in-flight requests. Register it before recover so recovered panics are
+// counted as 500s.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		metrics.RequestsInFlight.Inc()
+		defer metrics.RequestsInFlight.Dec()
+
+		err := c.Next()
+
+		route, status := routeStatus(c, err)
+		metrics.RequestsTotal.WithLabelValues(c.Method(), route, strconv.Itoa(status)).Inc()
+		metrics.RequestDuration.WithLabelValues(c.Method(), route).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// routeStatus returns the route template ("/api/v1/products/:id") and final
+// status of a handled request, given the error returned down the chain.
+// Requests that matched no route share the "unmatched" route so raw paths
+// never become labels.
+func routeStatus(c *fiber.Ctx, err error) (string, int) {
+	route := c.Route().Path
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+			if status == fiber.StatusNotFound {
+				route = "unmatched"
+			}
+		}
+	}
+	return route, status
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func requestCount(t *testing.T, method, route, status string) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := metrics.RequestsTotal.WithLabelValues(method, route, status).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestMetricsLabelsRouteTemplates(t *testing.T) {
+	app := fiber.New()
+	app.Use(Metrics())
+	app.Get("/metrics-test/items/:id", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	byTemplate := requestCount(t, http.MethodGet, "/metrics-test/items/:id", "204")
+	unmatched := requestCount(t, http.MethodGet, "unmatched", "404")
+
+	for _, path := range []string{"/metrics-test/items/1", "/metrics-test/items/2", "/metrics-test/nowhere"} {
+		if _, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil), -1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := requestCount(t, http.MethodGet, "/metrics-test/items/:id", "204") - byTemplate; got != 2 {
+		t.Fatalf("requests counted under the route template = %v, want 2", got)
+	}
+	if got := requestCount(t, http.MethodGet, "unmatched", "404") - unmatched; got != 1 {
+		t.Fatalf("unmatched requests counted = %v, want 1", got)
+	}
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireIfMatch returns a middleware that rejects writes without an If-Match
+// header with 428 Precondition Required, so clients cannot skip the lost
+// update check. It passes everything through unless cfg.RequireIfMatch is set;
+// handlers still honour If-Match whenever it is sent.
+func RequireIfMatch(cfg *config.App) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cfg.RequireIfMatch && c.Get(fiber.HeaderIfMatch) == "" {
+			return respond.Error(c, fiber.StatusPreconditionRequired,
+				"This request must be conditional; send If-Match with the resource's ETag")
+		}
+		return c.Next()
+	}
+}
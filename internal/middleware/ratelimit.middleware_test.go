@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRateLimiterTier(t *testing.T) {
+	limiter := NewRateLimiter(&config.App{
+		EnableLimiter: true,
+		RateLimitTiers: map[string]config.RateLimitTier{
+			"default": {Max: 2, Window: time.Hour},
+			"write":   {Max: 1, Window: time.Hour},
+		},
+	}, ratelimit.NewMemoryStore())
+
+	app := fiber.New(fiber.Config{ProxyHeader: fiber.HeaderXForwardedFor})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) }
+	app.Get("/", limiter.Tier("default"), ok)
+	app.Post("/", limiter.Tier("write"), ok)
+
+	hit := func(method, ip string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, "/", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, ip)
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	for i, want := range []int{fiber.StatusNoContent, fiber.StatusNoContent, fiber.StatusTooManyRequests} {
+		resp := hit(http.MethodGet, "10.0.0.1")
+		if resp.StatusCode != want {
+			t.Fatalf("request %d: status %d, want %d", i+1, resp.StatusCode, want)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "2;w=3600" {
+			t.Fatalf("RateLimit-Policy = %q", got)
+		}
+		if remaining := resp.Header.Get("RateLimit-Remaining"); remaining != []string{"1", "0", "0"}[i] {
+			t.Fatalf("request %d: RateLimit-Remaining = %s", i+1, remaining)
+		}
+		if retry := resp.Header.Get(fiber.HeaderRetryAfter); (want == fiber.StatusTooManyRequests) != (retry != "") {
+			t.Fatalf("request %d: Retry-After = %q", i+1, retry)
+		}
+	}
+
+	// Another caller and another tier have budgets of their own
+	if resp := hit(http.MethodGet, "10.0.0.2"); resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("another caller: status %d", resp.StatusCode)
+	}
+	if resp := hit(http.MethodPost, "10.0.0.1"); resp.StatusCode != fiber.StatusNoContent {
+		t.Fatalf("write tier: status %d", resp.StatusCode)
+	}
+	if resp := hit(http.MethodPost, "10.0.0.1"); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("write tier over its limit: status %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimiterUnconfiguredTier(t *testing.T) {
+	limiter := NewRateLimiter(&config.App{
+		EnableLimiter:  true,
+		RateLimitTiers: map[string]config.RateLimitTier{"default": {Max: 1, Window: time.Hour}},
+	}, ratelimit.NewMemoryStore())
+
+	// "export" takes the default limits but keeps a counter of its own, so
+	// passing both tiers spends one request from each
+	app := fiber.New()
+	app.Get("/", limiter.Tier("default"), limiter.Tier("export"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	for i, want := range []int{fiber.StatusNoContent, fiber.StatusTooManyRequests} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Fatalf("request %d: status %d, want %d", i+1, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// RBAC enforces per-route permissions using the role policy from config.
+type RBAC struct {
+	enabled bool
+	roles   map[string][]string
+}
+
+// NewRBAC builds an RBAC from cfg.RolePermissions. Role names are matched
+// case-insensitively.
+func NewRBAC(cfg *config.App) *RBAC {
+	roles := make(map[string][]string, len(cfg.RolePermissions))
+	for role, perms := range cfg.RolePermissions {
+		roles[strings.ToLower(role)] = perms
+	}
+	return &RBAC{enabled: cfg.AuthEnabled, roles: roles}
+}
+
+// Require returns a middleware that allows the request only if the caller's
+// roles or API key scopes grant permission. It must run after Authenticate.
+func (r *RBAC) Require(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !r.enabled {
+			return c.Next()
+		}
+
+		principal, ok := PrincipalFrom(c)
+		if !ok {
+			return unauthorized(c, "", "Missing bearer token or API key")
+		}
+		if !r.Allowed(principal, permission) {
+			return respond.Error(c, fiber.StatusForbidden, "Missing permission {0}", permission)
+		}
+		return c.Next()
+	}
+}
+
+// RequireWhen is Require for the requests cond selects; the others pass
+// through unchecked.
+func (r *RBAC) RequireWhen(cond func(*fiber.Ctx) bool, permission string) fiber.Handler {
+	require := r.Require(permission)
+	return func(c *fiber.Ctx) error {
+		if !cond(c) {
+			return c.Next()
+		}
+		return require(c)
+	}
+}
+
+// Allowed reports whether the principal's API key scopes or any of its roles
+// grant permission.
+func (r *RBAC) Allowed(principal *Principal, permission string) bool {
+	if grants(principal.Scopes, permission) {
+		return true
+	}
+	for _, role := range principal.Roles {
+		if grants(r.roles[strings.ToLower(role)], permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// grants reports whether granted covers permission, honouring "*" and
+// "resource:*" wildcards.
+func grants(granted []string, permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, g := range granted {
+		if g == "*" || g == permission || g == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
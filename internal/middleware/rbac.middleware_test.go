@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"testing"
+)
+
+func TestRBACAllowed(t *testing.T) {
+	rbac := NewRBAC(&config.App{AuthEnabled: true, RolePermissions: map[string][]string{
+		"Admin":  {"*"},
+		"editor": {"products:*", "brands:write"},
+		"viewer": {},
+	}})
+
+	tests := []struct {
+		roles      []string
+		scopes     []string
+		permission string
+		want       bool
+	}{
+		{[]string{"admin"}, nil, "categories:delete", true},
+		{[]string{"EDITOR"}, nil, "products:delete", true},
+		{[]string{"editor"}, nil, "brands:write", true},
+		{[]string{"editor"}, nil, "brands:delete", false},
+		{[]string{"editor"}, nil, "productsx:write", false},
+		{[]string{"viewer", "editor"}, nil, "products:write", true},
+		{[]string{"viewer"}, nil, "products:write", false},
+		{[]string{"unknown"}, nil, "products:write", false},
+		{nil, nil, "products:write", false},
+		{nil, []string{"products:write"}, "products:write", true},
+		{nil, []string{"products:*"}, "products:delete", true},
+		{nil, []string{"products:write"}, "brands:write", false},
+	}
+	for _, tt := range tests {
+		principal := &Principal{Roles: tt.roles, Scopes: tt.scopes}
+		if got := rbac.Allowed(principal, tt.permission); got != tt.want {
+			t.Errorf("Allowed(roles %v, scopes %v, %q) = %v, want %v", tt.roles, tt.scopes, tt.permission, got, tt.want)
+		}
+	}
+}
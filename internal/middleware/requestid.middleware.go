@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderRequestID carries the request ID in both directions.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength caps client-supplied IDs so they cannot bloat the logs.
+const maxRequestIDLength = 128
+
+// RequestID returns a middleware that tags each request with an ID, reusing a
+// well-formed X-Request-ID from the client (or an upstream proxy) and
+// generating one otherwise. The ID is echoed in the response header and stored
+// in the UserContext, where the logger and error envelopes pick it up.
+// Register it first so everything downstream can see the ID.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		c.Set(HeaderRequestID, id)
+		c.SetUserContext(logging.WithRequestID(c.UserContext(), id))
+		return c.Next()
+	}
+}
+
+// validRequestID accepts up to maxRequestIDLength printable ASCII characters
+// without spaces, which keeps log lines and headers intact.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex encoded.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"Scalable-Secure-Go-Web/internal/logging"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRequestID(t *testing.T) {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(logging.RequestID(c.UserContext()))
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"reused", "edge-7f3a9", true},
+		{"missing", "", false},
+		{"with a space", "two words", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderRequestID, tt.header)
+			}
+			resp, err := app.Test(req, -1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id := resp.Header.Get(HeaderRequestID)
+			if id != string(body) {
+				t.Fatalf("header ID %q differs from the context ID %q", id, body)
+			}
+			if tt.keep && id != tt.header {
+				t.Fatalf("ID = %q, want the client's %q", id, tt.header)
+			}
+			if !tt.keep && len(id) != 32 {
+				t.Fatalf("generated ID = %q, want 32 hex characters", id)
+			}
+		})
+	}
+}
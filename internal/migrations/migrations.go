@@ -0,0 +1,559 @@
+// Package migrations applies the versioned SQL schema migrations embedded in
+// sql/<driver>/.
+//
+// Each migration is a pair of files named NNNN_description.up.sql and
+// NNNN_description.down.sql. Applied versions are recorded in the
+// schema_migrations table. A run holds a database-wide lock (an advisory lock on
+// Postgres and MySQL, a write transaction on SQLite), so replicas starting at
+// the same time apply each migration exactly once.
+//
+// Statements are split on ";" outside quotes and comments. Trigger bodies
+// (CREATE TRIGGER ... END;) and $$-quoted Postgres function bodies are kept
+// whole. Each migration runs in its own transaction; note that MySQL commits
+// DDL implicitly, so a failing MySQL migration may be left half applied.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql
+var files embed.FS
+
+// lockName identifies the migration lock on MySQL; lockID on Postgres.
+const (
+	lockName = "catalog_schema_migrations"
+	lockID   = 7291846650
+)
+
+// lockTimeout bounds how long a run waits for another replica's migrations.
+const lockTimeout = 2 * time.Minute
+
+// ErrNoChange is returned when the schema is already at the requested version.
+var ErrNoChange = errors.New("no migrations to apply")
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	up      string
+	down    string
+}
+
+// Status describes a migration and whether it has been applied.
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator applies migrations for one database driver.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// New returns a Migrator for db using the migrations embedded for driver
+// ("sqlite", "postgres" or "mysql").
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// load reads and pairs the embedded files for driver, ordered by version.
+func load(driver string) ([]Migration, error) {
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q", driver)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(entry.Name(), ".sql"), ".")
+		rawVersion, name, ok2 := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if !ok || !ok2 || err != nil || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("bad migration file name %q", entry.Name())
+		}
+
+		body, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the highest known migration version, or 0 if there are none.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every known migration with its applied time, oldest first.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	done := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && done < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done++
+		}
+		return m.syncSearch(ctx, conn)
+	})
+	if err == nil && done == 0 {
+		err = ErrNoChange
+	}
+	return done, err
+}
+
+// To migrates up or down until exactly the migrations up to and including
+// version are applied. Version 0 rolls everything back.
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if version != 0 && !m.known(version) {
+		return 0, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	done := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// Roll back newer migrations first, newest to oldest
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; ok && migration.Version > version {
+				if err := m.apply(ctx, conn, migration, false); err != nil {
+					return err
+				}
+				done++
+			}
+		}
+
+		// Then apply missing ones, oldest to newest
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+				if err := m.apply(ctx, conn, migration, true); err != nil {
+					return err
+				}
+				done++
+			}
+		}
+		return m.syncSearch(ctx, conn)
+	})
+	if err == nil && done == 0 {
+		err = ErrNoChange
+	}
+	return done, err
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// execer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// placeholder returns the n-th (1-based) bind parameter for the driver.
+func (m *Migrator) placeholder(n int) string {
+	if m.driver == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint NOT NULL PRIMARY KEY,
+    name varchar(255) NOT NULL,
+    applied_at timestamp NOT NULL
+)`)
+	return err
+}
+
+// applied returns the applied versions with their timestamps, creating the
+// schema_migrations table if needed.
+func (m *Migrator) applied(ctx context.Context, db execer) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx, db); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// apply runs one migration in the given direction and records it.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	body, record, args := migration.down, "DELETE FROM schema_migrations WHERE version = "+m.placeholder(1), []any{migration.Version}
+	if up {
+		body = migration.up
+		record = fmt.Sprintf("INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)",
+			m.placeholder(1), m.placeholder(2), m.placeholder(3))
+		args = []any{migration.Version, migration.Name, time.Now().UTC()}
+	}
+
+	err := m.inTx(ctx, conn, func(tx execer) error {
+		for _, statement := range splitStatements(body) {
+			if _, err := tx.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, record, args...)
+		return err
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return fmt.Errorf("migration %04d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+// inTx runs fn atomically. SQLite already holds the run-wide transaction from
+// withLock, so it uses a savepoint instead.
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, fn func(execer) error) error {
+	if m.driver == "sqlite" {
+		if _, err := conn.ExecContext(ctx, "SAVEPOINT migration"); err != nil {
+			return err
+		}
+		if err := fn(conn); err != nil {
+			_, _ = conn.ExecContext(ctx, "ROLLBACK TO migration")
+			_, _ = conn.ExecContext(ctx, "RELEASE migration")
+			return err
+		}
+		_, err := conn.ExecContext(ctx, "RELEASE migration")
+		return err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock runs fn on a single connection while holding the migration lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	switch m.driver {
+	case "postgres":
+		if _, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	case "mysql":
+		var got sql.NullInt64
+		if err := conn.QueryRowContext(lockCtx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&got); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+		if got.Int64 != 1 {
+			return fmt.Errorf("acquire migration lock: timed out after %s", lockTimeout)
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+
+	case "sqlite":
+		// BEGIN IMMEDIATE takes the database write lock; retry while another
+		// process holds it
+		for {
+			_, err := conn.ExecContext(lockCtx, "BEGIN IMMEDIATE")
+			if err == nil {
+				break
+			}
+			if !strings.Contains(err.Error(), "locked") && !strings.Contains(err.Error(), "busy") {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			select {
+			case <-lockCtx.Done():
+				return fmt.Errorf("acquire migration lock: %w", lockCtx.Err())
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		// Failed migrations were rolled back to their savepoint, so whatever
+		// remains in the transaction succeeded and is committed
+		runErr := fn(conn)
+		if _, err := conn.ExecContext(context.Background(), "COMMIT"); err != nil {
+			_, _ = conn.ExecContext(context.Background(), "ROLLBACK")
+			return errors.Join(runErr, err)
+		}
+		return runErr
+
+	default:
+		return fmt.Errorf("unsupported driver %q", m.driver)
+	}
+
+	return fn(conn)
+}
+
+// splitStatements splits a migration file into individual statements, each
+// ending at a ";" that is outside quotes, comments, dollar-quoted ($$ or
+// $tag$) bodies and trigger bodies. A quote ends at the next matching quote,
+// a doubled one standing for itself. Comments between statements are dropped.
+func splitStatements(body string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		words      int    // words seen in the current statement
+		first      string // its first word
+		trigger    bool   // it is a CREATE ... TRIGGER
+		depth      int    // BEGIN/CASE ... END nesting inside a trigger
+	)
+	started := func() bool { return strings.TrimSpace(current.String()) != "" }
+
+	for i := 0; i < len(body); {
+		c := body[i]
+		switch {
+		case strings.HasPrefix(body[i:], "--"), strings.HasPrefix(body[i:], "/*"):
+			n := commentLength(body[i:])
+			if started() {
+				current.WriteString(body[i : i+n])
+			}
+			i += n
+
+		case c == '\'' || c == '"' || c == '`':
+			n := quotedLength(body[i:])
+			current.WriteString(body[i : i+n])
+			i += n
+
+		case c == '$' && dollarTag(body[i:]) != "":
+			tag := dollarTag(body[i:])
+			n := len(body) - i
+			if end := strings.Index(body[i+len(tag):], tag); end >= 0 {
+				n = len(tag) + end + len(tag)
+			}
+			current.WriteString(body[i : i+n])
+			i += n
+
+		case c == ';':
+			current.WriteByte(c)
+			i++
+			if depth == 0 {
+				statements = append(statements, strings.TrimSpace(current.String()))
+				current.Reset()
+				words, first, trigger = 0, "", false
+			}
+
+		case isWordByte(c):
+			n := wordLength(body[i:])
+			word := strings.ToUpper(body[i : i+n])
+			current.WriteString(body[i : i+n])
+			i += n
+
+			words++
+			switch {
+			case words == 1:
+				first = word
+			// CREATE [TEMP] TRIGGER, or MySQL's CREATE DEFINER=... TRIGGER
+			case first == "CREATE" && word == "TRIGGER" && words <= 6:
+				trigger = true
+			case trigger && (word == "BEGIN" || word == "CASE"):
+				depth++
+			case trigger && word == "END" && depth > 0:
+				depth--
+				// END IF, END LOOP etc. close blocks that were never counted;
+				// END CASE closes the CASE that was
+				rest := strings.TrimLeft(body[i:], " \t\r\n")
+				switch next := strings.ToUpper(rest[:wordLength(rest)]); next {
+				case "IF", "LOOP", "WHILE", "REPEAT":
+					depth++
+					fallthrough
+				case "CASE":
+					n := len(body) - len(rest) + len(next) - i
+					current.WriteString(body[i : i+n])
+					i += n
+				}
+			}
+
+		default:
+			current.WriteByte(c)
+			i++
+		}
+	}
+
+	if started() {
+		statements = append(statements, strings.TrimSpace(current.String()))
+	}
+	return statements
+}
+
+// commentLength returns the length of the "--" or "/* */" comment s starts
+// with, up to the end of s if it is not closed.
+func commentLength(s string) int {
+	if strings.HasPrefix(s, "--") {
+		if end := strings.IndexByte(s, '\n'); end >= 0 {
+			return end
+		}
+		return len(s)
+	}
+	if end := strings.Index(s[2:], "*/"); end >= 0 {
+		return end + 4
+	}
+	return len(s)
+}
+
+// quotedLength returns the length of the quoted string or identifier s starts
+// with, up to the end of s if it is not closed.
+func quotedLength(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s)
+}
+
+// dollarTag returns the Postgres dollar quote ($$ or $name$) s starts with,
+// or "" if there is none.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case !isWordByte(s[i]) || (i == 1 && s[i] >= '0' && s[i] <= '9'):
+			return ""
+		}
+	}
+	return ""
+}
+
+func wordLength(s string) int {
+	n := 0
+	for n < len(s) && isWordByte(s[n]) {
+		n++
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
@@ -0,0 +1,276 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "one per line",
+			body: "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n",
+			want: []string{"CREATE TABLE a (id int);", "CREATE TABLE b (id int);"},
+		},
+		{
+			name: "several on a line",
+			body: "DROP TABLE a; DROP TABLE b;",
+			want: []string{"DROP TABLE a;", "DROP TABLE b;"},
+		},
+		{
+			name: "comments between statements",
+			body: "-- Create a.\n/* and b; later */\nCREATE TABLE a (id int);\n\n-- done\n",
+			want: []string{"CREATE TABLE a (id int);"},
+		},
+		{
+			name: "trailing comment",
+			body: "CREATE TABLE a (id int); -- the first table\nCREATE TABLE b (id int); -- the second; really\n",
+			want: []string{"CREATE TABLE a (id int);", "CREATE TABLE b (id int);"},
+		},
+		{
+			name: "comment inside a statement",
+			body: "CREATE TABLE a (\n    id int -- key; not null\n);\n",
+			want: []string{"CREATE TABLE a (\n    id int -- key; not null\n);"},
+		},
+		{
+			name: "string ending in a semicolon at end of line",
+			body: "INSERT INTO notes (body) VALUES ('first;\nsecond');\nINSERT INTO notes (body) VALUES ('it''s; -- not a comment');\n",
+			want: []string{
+				"INSERT INTO notes (body) VALUES ('first;\nsecond');",
+				"INSERT INTO notes (body) VALUES ('it''s; -- not a comment');",
+			},
+		},
+		{
+			name: "quoted identifiers",
+			body: "CREATE TABLE `a;b` (\"c;\" int);\nSELECT 1;",
+			want: []string{"CREATE TABLE `a;b` (\"c;\" int);", "SELECT 1;"},
+		},
+		{
+			name: "trigger body",
+			body: `CREATE TRIGGER IF NOT EXISTS audit AFTER UPDATE ON products BEGIN
+    INSERT INTO log (id) VALUES (new.id);
+    UPDATE counts SET n = CASE WHEN n IS NULL THEN 1 ELSE n + 1 END;
+END;
+DROP TABLE old;`,
+			want: []string{
+				`CREATE TRIGGER IF NOT EXISTS audit AFTER UPDATE ON products BEGIN
+    INSERT INTO log (id) VALUES (new.id);
+    UPDATE counts SET n = CASE WHEN n IS NULL THEN 1 ELSE n + 1 END;
+END;`,
+				"DROP TABLE old;",
+			},
+		},
+		{
+			name: "MySQL trigger with IF",
+			body: "CREATE DEFINER=`app`@`%` TRIGGER stamp BEFORE UPDATE ON products FOR EACH ROW BEGIN\n    IF NEW.price < 0 THEN\n        SET NEW.price = 0;\n    END IF;\nEND;\nSELECT 1;",
+			want: []string{
+				"CREATE DEFINER=`app`@`%` TRIGGER stamp BEFORE UPDATE ON products FOR EACH ROW BEGIN\n    IF NEW.price < 0 THEN\n        SET NEW.price = 0;\n    END IF;\nEND;",
+				"SELECT 1;",
+			},
+		},
+		{
+			name: "dollar-quoted function",
+			body: `CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now(); -- keep it; fresh
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER touch BEFORE UPDATE ON products FOR EACH ROW EXECUTE FUNCTION touch();`,
+			want: []string{
+				`CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now(); -- keep it; fresh
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;`,
+				"CREATE TRIGGER touch BEFORE UPDATE ON products FOR EACH ROW EXECUTE FUNCTION touch();",
+			},
+		},
+		{
+			name: "tagged dollar quote",
+			body: "DO $body$ BEGIN PERFORM 1; END $body$;\nSELECT $1;",
+			want: []string{"DO $body$ BEGIN PERFORM 1; END $body$;", "SELECT $1;"},
+		},
+		{
+			name: "no final semicolon",
+			body: "SELECT 1;\nSELECT 2\n-- end\n",
+			want: []string{"SELECT 1;", "SELECT 2\n-- end"},
+		},
+		{
+			name: "only comments",
+			body: "-- nothing to do here;\n\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSplitEmbedded checks that every embedded migration splits into
+// statements that each end with ";" and leave no SQL behind.
+func TestSplitEmbedded(t *testing.T) {
+	for _, driver := range []string{"sqlite", "postgres", "mysql"} {
+		migrations, err := load(driver)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range migrations {
+			for direction, body := range map[string]string{"up": m.up, "down": m.down} {
+				t.Run(driver+"/"+m.Name+"."+direction, func(t *testing.T) {
+					statements := splitStatements(body)
+					if got, want := len(statements), countStatements(body); got != want {
+						t.Fatalf("%d statements, want %d: %q", got, want, statements)
+					}
+					for _, statement := range statements {
+						if !strings.HasSuffix(statement, ";") || strings.HasPrefix(statement, "--") {
+							t.Fatalf("malformed statement %q", statement)
+						}
+					}
+				})
+			}
+		}
+	}
+
+	// The triggers of the SQLite search index each stay whole
+	statements := splitStatements(sqliteSearchSchema)
+	if len(statements) != 5 {
+		t.Fatalf("search schema has %d statements, want 5: %q", len(statements), statements)
+	}
+	for _, statement := range statements[1:4] {
+		if !strings.HasPrefix(statement, "CREATE TRIGGER") || !strings.HasSuffix(statement, "END;") {
+			t.Fatalf("trigger was split: %q", statement)
+		}
+	}
+}
+
+// countStatements counts the lines of body that end a statement in the
+// embedded files, none of which has a multi-line string, trigger or function.
+func countStatements(body string) int {
+	n := 0
+	for _, line := range strings.Split(body, "\n") {
+		line, _, _ = strings.Cut(line, "--")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			n++
+		}
+	}
+	return n
+}
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "catalog.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+// tables lists the tables in the SQLite database, schema_migrations aside.
+func tables(t *testing.T, db *sql.DB) []string {
+	t.Helper()
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations' ORDER BY name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openSQLite(t)
+	migrator, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := migrator.Latest()
+
+	done, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(done) != latest {
+		t.Fatalf("Up applied %d migrations, want %d", done, latest)
+	}
+	schema := tables(t, db)
+	if !reflect.DeepEqual(schema[:3], []string{"api_keys", "brands", "categories"}) {
+		t.Fatalf("tables after Up = %v", schema)
+	}
+	if _, err := migrator.Up(ctx); !errors.Is(err, ErrNoChange) {
+		t.Fatalf("second Up: err = %v, want ErrNoChange", err)
+	}
+
+	// Each version rolls back and reapplies cleanly
+	for version := latest - 1; version >= 0; version-- {
+		if _, err := migrator.To(ctx, version); err != nil {
+			t.Fatalf("To(%d): %v", version, err)
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int64(len(pending)) != latest-version {
+			t.Fatalf("after To(%d): %d pending, want %d", version, len(pending), latest-version)
+		}
+	}
+	if got := tables(t, db); len(got) != 0 {
+		t.Fatalf("tables after rolling everything back = %v", got)
+	}
+
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := tables(t, db); !reflect.DeepEqual(got, schema) {
+		t.Fatalf("tables after reapplying = %v, want %v", got, schema)
+	}
+	if done, err := migrator.Down(ctx, 2); err != nil || done != 2 {
+		t.Fatalf("Down(2) = %d, %v; want 2, nil", done, err)
+	}
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if applied := status.AppliedAt != nil; applied != (status.Version <= latest-2) {
+			t.Fatalf("migration %d applied = %v after Down(2)", status.Version, applied)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+)
+
+// sqliteSearchVersion is the migration that turns on product search.
+const sqliteSearchVersion = 6
+
+// sqliteSearchTriggers keep products_fts in step with products.
+var sqliteSearchTriggers = []string{"products_fts_insert", "products_fts_delete", "products_fts_update"}
+
+// sqliteSearchSchema indexes product names and descriptions with FTS5. The
+// index stores no copy of the text (content='products'); the triggers feed it
+// every write, and 'rebuild' indexes the rows that were written while there
+// were no triggers.
+const sqliteSearchSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+    name, description,
+    content='products', content_rowid='id',
+    tokenize='porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+    INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+    INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name, description ON products BEGIN
+    INSERT INTO products_fts(products_fts, rowid, name, description) VALUES ('delete', old.id, old.name, old.description);
+    INSERT INTO products_fts(rowid, name, description) VALUES (new.id, new.name, new.description);
+END;
+
+INSERT INTO products_fts(products_fts) VALUES ('rebuild');
+`
+
+// syncSearch brings the SQLite FTS5 index in line with the applied migrations
+// and the binary, at the end of every run. The index exists while
+// sqliteSearchVersion is applied and the SQLite library has FTS5; otherwise
+// its triggers are dropped, since without FTS5 they would fail every product
+// write, and product search falls back to LIKE scans. Other drivers index
+// search in their numbered migrations.
+func (m *Migrator) syncSearch(ctx context.Context, conn *sql.Conn) error {
+	if m.driver != "sqlite" {
+		return nil
+	}
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+	var fts5 bool
+	if err := conn.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+		return err
+	}
+	_, enabled := applied[sqliteSearchVersion]
+
+	return m.inTx(ctx, conn, func(tx execer) error {
+		if enabled && fts5 {
+			var present int
+			err := tx.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'trigger' AND name IN (?, ?, ?)",
+				sqliteSearchTriggers[0], sqliteSearchTriggers[1], sqliteSearchTriggers[2]).Scan(&present)
+			if err != nil || present == len(sqliteSearchTriggers) {
+				return err
+			}
+			for _, statement := range splitStatements(sqliteSearchSchema) {
+				if _, err := tx.ExecContext(ctx, statement); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		for _, trigger := range sqliteSearchTriggers {
+			if _, err := tx.ExecContext(ctx, "DROP TRIGGER IF EXISTS "+trigger); err != nil {
+				return err
+			}
+		}
+		// A virtual table can only be dropped by a library that has its module
+		if fts5 {
+			_, err := tx.ExecContext(ctx, "DROP TABLE IF EXISTS products_fts")
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,31 @@
+-- Catalog tables. IF NOT EXISTS adopts databases created by the old AutoMigrate.
+-- MySQL has no CREATE INDEX IF NOT EXISTS, so indexes are declared inline.
+CREATE TABLE IF NOT EXISTS `categories` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `title` varchar(100) NOT NULL,
+    `cover_image` text NOT NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL
+);
+
+CREATE TABLE IF NOT EXISTS `brands` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `name` varchar(100) NOT NULL,
+    `cover_image` text NOT NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL
+);
+
+CREATE TABLE IF NOT EXISTS `products` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `name` longtext NOT NULL,
+    `description` text NOT NULL,
+    `price` double NOT NULL,
+    `cover_image` text NOT NULL,
+    `category_id` bigint unsigned NOT NULL,
+    `brand_id` bigint unsigned NOT NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    CONSTRAINT `fk_products_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`),
+    CONSTRAINT `fk_products_brand` FOREIGN KEY (`brand_id`) REFERENCES `brands`(`id`)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+    `name` varchar(100) NOT NULL,
+    `prefix` varchar(32) NOT NULL,
+    `hash` varchar(64) NOT NULL,
+    `scopes` text NOT NULL,
+    `expires_at` datetime(3) NULL,
+    `last_used_at` datetime(3) NULL,
+    `revoked_at` datetime(3) NULL,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    UNIQUE INDEX `idx_api_keys_prefix` (`prefix`)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS `rate_limit_counters` (
+    `bucket` varchar(191) NOT NULL,
+    `window_start` bigint NOT NULL,
+    `count` bigint NOT NULL DEFAULT 0,
+    `expires_at` bigint NOT NULL,
+    PRIMARY KEY (`bucket`, `window_start`),
+    INDEX `idx_rate_limit_counters_expires_at` (`expires_at`)
+);
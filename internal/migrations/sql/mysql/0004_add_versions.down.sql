@@ -0,0 +1,3 @@
+ALTER TABLE `products` DROP COLUMN `version`;
+ALTER TABLE `brands` DROP COLUMN `version`;
+ALTER TABLE `categories` DROP COLUMN `version`;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS `idempotency_records` (
+    `scope` varchar(191) NOT NULL,
+    `idempotency_key` varchar(255) NOT NULL,
+    `fingerprint` varchar(64) NOT NULL,
+    `status_code` int NOT NULL DEFAULT 0,
+    `content_type` varchar(100) NOT NULL DEFAULT '',
+    `body` mediumtext NOT NULL,
+    `created_at` datetime(3) NOT NULL,
+    `expires_at` bigint NOT NULL,
+    PRIMARY KEY (`scope`, `idempotency_key`),
+    INDEX `idx_idempotency_records_expires_at` (`expires_at`)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE `products` DROP INDEX `idx_products_search_name`;
+ALTER TABLE `products` DROP INDEX `idx_products_search`;
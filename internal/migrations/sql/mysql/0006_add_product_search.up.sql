@@ -0,0 +1,5 @@
+-- Full-text search over product names and descriptions. InnoDB keeps the
+-- indexes in step with every write; the name-only index lets ranking weigh
+-- name matches above description matches.
+ALTER TABLE `products` ADD FULLTEXT INDEX `idx_products_search` (`name`, `description`);
+ALTER TABLE `products` ADD FULLTEXT INDEX `idx_products_search_name` (`name`);
@@ -0,0 +1,4 @@
+ALTER TABLE `products` DROP INDEX `idx_products_updated_at`;
+ALTER TABLE `brands` DROP INDEX `idx_brands_updated_at`;
+ALTER TABLE `categories` DROP INDEX `idx_categories_updated_at`;
+DROP TABLE IF EXISTS `table_deletions`;
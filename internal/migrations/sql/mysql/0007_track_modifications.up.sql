@@ -0,0 +1,10 @@
+-- List Last-Modified: the newest updated_at of a table, or its last deletion
+-- when that is newer, since hard-deleted rows leave no updated_at behind.
+CREATE TABLE IF NOT EXISTS `table_deletions` (
+    `table_name` varchar(64) NOT NULL PRIMARY KEY,
+    `last_deleted_at` datetime(3) NOT NULL
+);
+
+ALTER TABLE `categories` ADD INDEX `idx_categories_updated_at` (`updated_at`);
+ALTER TABLE `brands` ADD INDEX `idx_brands_updated_at` (`updated_at`);
+ALTER TABLE `products` ADD INDEX `idx_products_updated_at` (`updated_at`);
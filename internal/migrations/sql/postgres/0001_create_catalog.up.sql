@@ -0,0 +1,33 @@
+-- Catalog tables. IF NOT EXISTS adopts databases created by the old AutoMigrate.
+CREATE TABLE IF NOT EXISTS categories (
+    id bigserial PRIMARY KEY,
+    title varchar(100) NOT NULL,
+    cover_image text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS brands (
+    id bigserial PRIMARY KEY,
+    name varchar(100) NOT NULL,
+    cover_image text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id bigserial PRIMARY KEY,
+    name text NOT NULL,
+    description text NOT NULL,
+    price decimal NOT NULL,
+    cover_image text NOT NULL,
+    category_id bigint NOT NULL,
+    brand_id bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    CONSTRAINT fk_products_category FOREIGN KEY (category_id) REFERENCES categories(id),
+    CONSTRAINT fk_products_brand FOREIGN KEY (brand_id) REFERENCES brands(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
+CREATE INDEX IF NOT EXISTS idx_products_brand_id ON products(brand_id);
@@ -0,0 +1,3 @@
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+ALTER TABLE brands DROP COLUMN IF EXISTS version;
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_updated_at;
+DROP INDEX IF EXISTS idx_brands_updated_at;
+DROP INDEX IF EXISTS idx_categories_updated_at;
+DROP TABLE IF EXISTS table_deletions;
@@ -0,0 +1,10 @@
+-- List Last-Modified: the newest updated_at of a table, or its last deletion
+-- when that is newer, since hard-deleted rows leave no updated_at behind.
+CREATE TABLE IF NOT EXISTS table_deletions (
+    table_name varchar(64) PRIMARY KEY,
+    last_deleted_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_updated_at ON categories(updated_at);
+CREATE INDEX IF NOT EXISTS idx_brands_updated_at ON brands(updated_at);
+CREATE INDEX IF NOT EXISTS idx_products_updated_at ON products(updated_at);
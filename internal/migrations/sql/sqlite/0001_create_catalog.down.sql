@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `products`;
+DROP TABLE IF EXISTS `brands`;
+DROP TABLE IF EXISTS `categories`;
@@ -0,0 +1,33 @@
+-- Catalog tables. IF NOT EXISTS adopts databases created by the old AutoMigrate.
+CREATE TABLE IF NOT EXISTS `categories` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `title` varchar(100) NOT NULL,
+    `cover_image` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE TABLE IF NOT EXISTS `brands` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` varchar(100) NOT NULL,
+    `cover_image` text NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE TABLE IF NOT EXISTS `products` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL,
+    `description` text NOT NULL,
+    `price` real NOT NULL,
+    `cover_image` text NOT NULL,
+    `category_id` integer NOT NULL,
+    `brand_id` integer NOT NULL,
+    `created_at` datetime,
+    `updated_at` datetime,
+    CONSTRAINT `fk_products_category` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`),
+    CONSTRAINT `fk_products_brand` FOREIGN KEY (`brand_id`) REFERENCES `brands`(`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_products_category_id` ON `products`(`category_id`);
+CREATE INDEX IF NOT EXISTS `idx_products_brand_id` ON `products`(`brand_id`);
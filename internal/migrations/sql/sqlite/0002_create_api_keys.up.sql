@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `api_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` varchar(100) NOT NULL,
+    `prefix` varchar(32) NOT NULL,
+    `hash` varchar(64) NOT NULL,
+    `scopes` text NOT NULL,
+    `expires_at` datetime,
+    `last_used_at` datetime,
+    `revoked_at` datetime,
+    `created_at` datetime,
+    `updated_at` datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_api_keys_prefix` ON `api_keys`(`prefix`);
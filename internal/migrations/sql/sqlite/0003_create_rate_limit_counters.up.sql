@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `rate_limit_counters` (
+    `bucket` varchar(191),
+    `window_start` integer,
+    `count` integer NOT NULL DEFAULT 0,
+    `expires_at` integer NOT NULL,
+    PRIMARY KEY (`bucket`, `window_start`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_rate_limit_counters_expires_at` ON `rate_limit_counters`(`expires_at`);
@@ -0,0 +1,4 @@
+-- Row versions for optimistic concurrency (ETag / If-Match).
+ALTER TABLE `categories` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
+ALTER TABLE `brands` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
+ALTER TABLE `products` ADD COLUMN `version` integer NOT NULL DEFAULT 1;
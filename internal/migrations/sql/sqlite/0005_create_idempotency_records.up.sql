@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS `idempotency_records` (
+    `scope` varchar(191),
+    `idempotency_key` varchar(255),
+    `fingerprint` varchar(64) NOT NULL,
+    `status_code` integer NOT NULL DEFAULT 0,
+    `content_type` varchar(100) NOT NULL DEFAULT '',
+    `body` text NOT NULL DEFAULT '',
+    `created_at` datetime NOT NULL,
+    `expires_at` integer NOT NULL,
+    PRIMARY KEY (`scope`, `idempotency_key`)
+);
+
+CREATE INDEX IF NOT EXISTS `idx_idempotency_records_expires_at` ON `idempotency_records`(`expires_at`);
@@ -0,0 +1,2 @@
+-- The migrator drops the FTS5 index and its triggers once this version is
+-- rolled back (see internal/migrations/search.go).
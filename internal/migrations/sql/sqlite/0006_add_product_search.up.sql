@@ -0,0 +1,5 @@
+-- Full-text search over product names and descriptions.
+-- The FTS5 index is not created here: go-sqlite3 only has FTS5 when built with
+-- -tags sqlite_fts5, so the migrator creates the index and its triggers after
+-- every run while this version is applied, and only if the binary can (see
+-- internal/migrations/search.go).
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// APIKey is a partner credential. Only a SHA-256 hash of the key is stored;
+// the plaintext is returned once, when the key is created.
+// @Description API key metadata
+type APIKey struct {
+	ID         uint       `json:"id" example:"1" gorm:"primaryKey;autoIncrement"`
+	Name       string     `json:"name" example:"Acme importer" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" example:"sk_3f9a1c2b7d4e6a05" gorm:"type:varchar(32);uniqueIndex;not null"`
+	Hash       string     `json:"-" gorm:"type:varchar(64);not null"`
+	Scopes     []string   `json:"scopes" example:"products:write" gorm:"type:text;serializer:json;not null"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2025-07-09T15:04:05Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-07-09T15:04:05Z"`
+	UpdatedAt  time.Time  `json:"updated_at" example:"2025-07-09T15:04:05Z"`
+}
+
+// Active reports whether the key may still be used at time now.
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// APIKeyInput is the body accepted when creating an API key.
+// @Description API key creation request
+type APIKeyInput struct {
+	Name      string     `json:"name" example:"Acme importer" validate:"required,min=2,max=100"`
+	Scopes    []string   `json:"scopes" example:"products:write" validate:"required,min=1,dive,required,max=64"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`
+}
+
+// APIKeyCreated is returned once on creation and carries the plaintext key.
+// @Description Newly created API key, including the secret
+type APIKeyCreated struct {
+	APIKey
+	Key string `json:"key" example:"sk_3f9a1c2b7d4e6a05_Yk3...."`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// TableDeletion records when rows were last deleted from a table. Rows are
+// hard-deleted and leave nothing behind to date, so a list's Last-Modified is
+// the newer of its table's latest updated_at and this.
+type TableDeletion struct {
+	Table         string    `gorm:"column:table_name;type:varchar(64);primaryKey"`
+	LastDeletedAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a POST sent with an
+// Idempotency-Key. Rows are keyed by caller (scope) and key, hold a SHA-256
+// fingerprint of the request, and are pruned once expired. StatusCode is zero
+// while the first request is still being processed.
+type IdempotencyRecord struct {
+	Scope       string    `gorm:"type:varchar(191);primaryKey"`
+	Key         string    `gorm:"column:idempotency_key;type:varchar(255);primaryKey"`
+	Fingerprint string    `gorm:"type:varchar(64);not null"`
+	StatusCode  int       `gorm:"not null;default:0"`
+	ContentType string    `gorm:"type:varchar(100);not null"`
+	Body        string    `gorm:"type:text;not null"`
+	CreatedAt   time.Time `gorm:"not null"`
+	ExpiresAt   int64     `gorm:"index;not null"`
+}
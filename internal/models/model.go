@@ -2,8 +2,120 @@ package models
 
 // APIResponse defines a standard response structure
 type APIResponse struct {
-	Status     string      `json:"status"`
-	StatusCode int         `json:"status_code"`
-	Data       interface{} `json:"data"`
-	Message    string      `json:"message"`
+	Status     string          `json:"status"`
+	StatusCode int             `json:"status_code"`
+	Data       interface{}     `json:"data"`
+	Message    string          `json:"message"`
+	Meta       *PaginationMeta `json:"meta,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details document, served as
+// application/problem+json for every error response. Code is stable and meant
+// for clients to switch on; Detail is for humans and may change.
+// @Description RFC 7807 error response
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail,omitempty" example:"price must be greater than 0"`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/products"`
+	Code      string       `json:"code" example:"validation_failed"`
+	RequestID string       `json:"request_id,omitempty" example:"4e97e273e3e301e390e8dde2cccc565a"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid input field of a validation_failed problem.
+// @Description A single invalid field
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Rule    string `json:"rule" example:"gt"`
+	Param   string `json:"param,omitempty" example:"0"`
+	Message string `json:"message" example:"price must be greater than 0"`
+}
+
+// PaginationMeta describes the page returned by a list endpoint.
+// Offset pagination fills Total, Page and TotalPages; cursor pagination
+// leaves them out because counting defeats the purpose of a keyset scan.
+// @Description Pagination details for list responses
+type PaginationMeta struct {
+	Total      *int64 `json:"total,omitempty" example:"42"`
+	Page       int    `json:"page,omitempty" example:"2"`
+	Limit      int    `json:"limit" example:"10"`
+	TotalPages *int   `json:"total_pages,omitempty" example:"5"`
+	Next       string `json:"next,omitempty" example:"/api/v1/products?limit=10&page=3"`
+	Prev       string `json:"prev,omitempty" example:"/api/v1/products?limit=10&page=1"`
+	NextCursor string `json:"next_cursor,omitempty" example:"eyJzIjoiaWQ6YXNjIiwiciI6eyJpZCI6MTB9fQ.c2lnbmF0dXJl"`
+	PrevCursor string `json:"prev_cursor,omitempty" example:"eyJzIjoiaWQ6YXNjIiwiciI6eyJpZCI6MX19.c2lnbmF0dXJl"`
+}
+
+// BulkDelete names one record of a bulk delete. A non-zero Version must match
+// the stored one, like If-Match on a single DELETE.
+// @Description One record to delete
+type BulkDelete struct {
+	ID      uint `json:"id" example:"7" validate:"required"`
+	Version uint `json:"version,omitempty" example:"3"`
+}
+
+// BulkResult is the data of a bulk response, with one item per request item
+// in request order.
+// @Description Outcome of a bulk request
+type BulkResult struct {
+	Mode      string           `json:"mode" example:"partial"`
+	Succeeded int              `json:"succeeded" example:"2"`
+	Failed    int              `json:"failed" example:"1"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// BulkItemResult is the outcome of one bulk item. Status is what the item
+// would have got as a request of its own; a failed item carries the problem's
+// Code, Detail and Errors.
+// @Description Outcome of one bulk item
+type BulkItemResult struct {
+	Index  int          `json:"index" example:"0"`
+	Status int          `json:"status" example:"201"`
+	ID     uint         `json:"id,omitempty" example:"7"`
+	Data   interface{}  `json:"data,omitempty"`
+	Code   string       `json:"code,omitempty" example:"validation_failed"`
+	Detail string       `json:"detail,omitempty" example:"price must be greater than 0"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// ImportReport is the outcome of a product import. Rows that failed were
+// skipped; the others were created.
+// @Description Outcome of a product import
+type ImportReport struct {
+	Rows              int              `json:"rows" example:"120"`
+	Created           int              `json:"created" example:"118"`
+	Failed            int              `json:"failed" example:"2"`
+	CreatedBrands     []string         `json:"created_brands,omitempty" example:"Acme"`
+	CreatedCategories []string         `json:"created_categories,omitempty" example:"Headphones"`
+	Errors            []ImportRowError `json:"errors,omitempty"`
+}
+
+// ImportRowError lists what is wrong with one row of an import feed. Row is
+// the line the row starts on; an error without a field concerns the whole
+// row.
+// @Description Errors of one import row
+type ImportRowError struct {
+	Row    int          `json:"row" example:"14"`
+	Errors []FieldError `json:"errors"`
+}
+
+// SearchHit is one product found by a full-text search. Score is the
+// relevance the database assigned: higher is better, but values are only
+// comparable within one result set. Highlights repeat the matched fields as
+// HTML, every match wrapped in <mark>; the rest of the text is escaped.
+// @Description A product matching a search, with its relevance and highlights
+type SearchHit struct {
+	Product    Product          `json:"product"`
+	Score      float64          `json:"score" example:"0.42"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights holds the name of a hit and an excerpt of its description
+// around the matches, as HTML.
+// @Description Matched text with every match wrapped in <mark>
+type SearchHighlights struct {
+	Name        string `json:"name" example:"<mark>Wireless</mark> Headphones"`
+	Description string `json:"description" example:"… over-ear <mark>wireless</mark> headphones with …"`
 }
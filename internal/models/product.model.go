@@ -11,9 +11,10 @@ type Product struct {
 	Price       float64   `json:"price" example:"999.99"                                gorm:"not null"                     validate:"required,gt=0"`
 	CoverImage  string    `json:"cover_image" example:"https://example.com/iphone14.jpg" gorm:"type:text;not null"          validate:"required,url"`
 	CategoryID  uint      `json:"category_id" example:"2" gorm:"not null"               validate:"required"`
-	Category    Category  `json:"category" gorm:"foreignKey:CategoryID"`
+	Category    Category  `json:"category" gorm:"foreignKey:CategoryID"                 validate:"-"`
 	BrandID     uint      `json:"brand_id" example:"1" gorm:"not null"                  validate:"required"`
-	Brand       Brand     `json:"brand" gorm:"foreignKey:BrandID"`
+	Brand       Brand     `json:"brand" gorm:"foreignKey:BrandID"                       validate:"-"`
+	Version     uint      `json:"version" example:"3" gorm:"not null;default:1"`
 	CreatedAt   time.Time `json:"created_at" example:"2025-07-09T15:04:05Z"`
 	UpdatedAt   time.Time `json:"updated_at" example:"2025-07-09T15:04:05Z"`
 }
@@ -24,6 +25,7 @@ type Brand struct {
 	ID         uint      `json:"id" example:"1" gorm:"primaryKey;autoIncrement"`
 	Name       string    `json:"name" example:"Apple"               gorm:"type:varchar(100);not null" validate:"required,min=2,max=100"`
 	CoverImage string    `json:"cover_image" example:"https://example.com/apple.png" gorm:"type:text;not null" validate:"required,url"`
+	Version    uint      `json:"version" example:"1" gorm:"not null;default:1"`
 	CreatedAt  time.Time `json:"created_at" example:"2025-07-09T15:04:05Z"`
 	UpdatedAt  time.Time `json:"updated_at" example:"2025-07-09T15:04:05Z"`
 }
@@ -34,6 +36,7 @@ type Category struct {
 	ID         uint      `json:"id" example:"1" gorm:"primaryKey;autoIncrement"`
 	Title      string    `json:"title" example:"Smartphones"        gorm:"type:varchar(100);not null" validate:"required,min=2,max=100"`
 	CoverImage string    `json:"cover_image" example:"https://example.com/smartphones.jpg" gorm:"type:text;not null" validate:"required,url"`
+	Version    uint      `json:"version" example:"1" gorm:"not null;default:1"`
 	CreatedAt  time.Time `json:"created_at" example:"2025-07-09T15:04:05Z"`
 	UpdatedAt  time.Time `json:"updated_at" example:"2025-07-09T15:04:05Z"`
 }
@@ -0,0 +1,10 @@
+package models
+
+// RateLimitCounter is one fixed-window hit counter of the SQL rate limit store.
+// Rows are keyed by bucket and window start (Unix seconds) and pruned once expired.
+type RateLimitCounter struct {
+	Bucket      string `gorm:"type:varchar(191);primaryKey"`
+	WindowStart int64  `gorm:"primaryKey;autoIncrement:false"`
+	Count       int    `gorm:"not null;default:0"`
+	ExpiresAt   int64  `gorm:"index;not null"`
+}
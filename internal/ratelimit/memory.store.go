@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps counters in process. Limits reset on restart and are not
+// shared between replicas.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[memoryKey]*memoryCounter
+	nextPrune time.Time
+}
+
+type memoryKey struct {
+	bucket      string
+	windowStart int64
+}
+
+type memoryCounter struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[memoryKey]*memoryCounter)}
+}
+
+func (s *MemoryStore) Increment(_ context.Context, bucket string, windowStart, expiresAt time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextPrune) {
+		for key, counter := range s.counters {
+			if now.After(counter.expiresAt) {
+				delete(s.counters, key)
+			}
+		}
+		s.nextPrune = now.Add(pruneInterval)
+	}
+
+	key := memoryKey{bucket: bucket, windowStart: windowStart.Unix()}
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &memoryCounter{expiresAt: expiresAt}
+		s.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, nil
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQLStore keeps counters in the rate_limit_counters table, so every replica
+// sharing the database enforces the same limits. Increments are single upserts
+// and work on SQLite, Postgres and MySQL.
+type SQLStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	nextPrune time.Time
+}
+
+// NewSQLStore returns a Store backed by db. The rate_limit_counters table must
+// already exist.
+func NewSQLStore(db *gorm.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+func (s *SQLStore) Increment(ctx context.Context, bucket string, windowStart, expiresAt time.Time) (int, error) {
+	s.prune(ctx)
+
+	counter := models.RateLimitCounter{
+		Bucket:      bucket,
+		WindowStart: windowStart.Unix(),
+		Count:       1,
+		ExpiresAt:   expiresAt.Unix(),
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "bucket"}, {Name: "window_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("rate_limit_counters.count + 1")}),
+		}).Create(&counter).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RateLimitCounter{}).
+			Select("count").
+			Where("bucket = ? AND window_start = ?", counter.Bucket, counter.WindowStart).
+			Scan(&counter.Count).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return counter.Count, nil
+}
+
+// prune deletes expired counters at most once per pruneInterval per process.
+func (s *SQLStore) prune(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := now.After(s.nextPrune)
+	if due {
+		s.nextPrune = now.Add(pruneInterval)
+	}
+	s.mu.Unlock()
+
+	if !due {
+		return
+	}
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", now.Unix()).Delete(&models.RateLimitCounter{}).Error; err != nil {
+		slog.WarnContext(ctx, "Failed to prune rate limit counters", "error", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Package ratelimit counts requests in fixed windows.
+//
+// Counters live behind the Store interface so limits can be kept in process
+// (MemoryStore) or shared by every replica through the database (SQLStore).
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Store records hits against fixed-window counters.
+type Store interface {
+	// Increment adds one hit to bucket's counter for the window starting at
+	// windowStart and returns the new count. The counter may be discarded once
+	// expiresAt has passed.
+	Increment(ctx context.Context, bucket string, windowStart, expiresAt time.Time) (int, error)
+}
+
+// pruneInterval is how often stores drop expired counters.
+const pruneInterval = time.Minute
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLStore)(nil)
+)
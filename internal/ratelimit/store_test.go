@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"Scalable-Secure-Go-Web/internal/migrations"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func stores(t *testing.T) map[string]Store {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "ratelimit.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.New(sqlDB, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Store{"memory": NewMemoryStore(), "sql": NewSQLStore(db)}
+}
+
+func TestStoreIncrement(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			window := time.Now().Truncate(time.Minute)
+			expires := window.Add(time.Minute)
+
+			for want := 1; want <= 3; want++ {
+				got, err := store.Increment(ctx, "default|alice", window, expires)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Fatalf("hit %d counted as %d", want, got)
+				}
+			}
+
+			// Other buckets and later windows start from one
+			for _, hit := range []struct {
+				bucket string
+				window time.Time
+			}{
+				{"default|bob", window},
+				{"write|alice", window},
+				{"default|alice", window.Add(time.Minute)},
+			} {
+				got, err := store.Increment(ctx, hit.bucket, hit.window, hit.window.Add(time.Minute))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != 1 {
+					t.Fatalf("first hit on %s at %v counted as %d", hit.bucket, hit.window, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+// APIKeyRepository defines persistence operations for API keys.
+type APIKeyRepository interface {
+	// List returns the requested page ordered by ID together with the total count.
+	List(ctx context.Context, page Page) ([]models.APIKey, int64, error)
+	FindByID(ctx context.Context, id uint) (*models.APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Create(ctx context.Context, key *models.APIKey) error
+	// Revoke marks the key revoked at the given time. Revoking twice keeps the first timestamp.
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	// Touch records that the key was used at the given time.
+	Touch(ctx context.Context, id uint, at time.Time) error
+}
+
+// gormAPIKeyRepository is the GORM-backed APIKeyRepository.
+type gormAPIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyRepository returns an APIKeyRepository backed by db.
+func NewGormAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &gormAPIKeyRepository{db: db}
+}
+
+func (r *gormAPIKeyRepository) List(ctx context.Context, page Page) ([]models.APIKey, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.APIKey{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).
+		Order("id").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&keys).Error
+
+	return keys, total, err
+}
+
+func (r *gormAPIKeyRepository) FindByID(ctx context.Context, id uint) (*models.APIKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("id = ?", id))
+}
+
+func (r *gormAPIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return r.first(r.db.WithContext(ctx).Where("prefix = ?", prefix))
+}
+
+func (r *gormAPIKeyRepository) first(db *gorm.DB) (*models.APIKey, error) {
+	var key models.APIKey
+
+	err := db.First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (r *gormAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *gormAPIKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	if _, err := r.FindByID(ctx, id); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (r *gormAPIKeyRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	// UpdateColumn leaves updated_at alone; usage is not a modification
+	return r.db.WithContext(ctx).
+		Model(&models.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
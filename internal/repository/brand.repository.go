@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// BrandRepository defines persistence operations for brands.
+type BrandRepository interface {
+	// List returns the requested page ordered by ID together with the total count.
+	List(ctx context.Context, page Page) ([]models.Brand, int64, error)
+	// ListAfter returns up to limit brands whose ID is above afterID, ordered
+	// by ID, so that a whole table can be walked without offsets.
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]models.Brand, error)
+	FindByID(ctx context.Context, id uint) (*models.Brand, error)
+	Create(ctx context.Context, brand *models.Brand) error
+	// Update saves brand if the stored row is still at brand.Version and
+	// increments it; otherwise it returns ErrVersionConflict.
+	Update(ctx context.Context, brand *models.Brand) error
+	// Delete removes the record if it is still at version (0: any version).
+	Delete(ctx context.Context, id, version uint) error
+	// FindByIDs returns the brands among ids that exist, ordered by ID.
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Brand, error)
+	// FindByNames returns the brands whose name matches one of names, ignoring
+	// case, ordered by ID.
+	FindByNames(ctx context.Context, names []string) ([]models.Brand, error)
+	// CreateMany inserts brands in one transaction: all of them or none.
+	CreateMany(ctx context.Context, brands []models.Brand) error
+	// UpdateMany applies Update to each of brands in one transaction. If one
+	// fails nothing is saved and the error is a *BatchError.
+	UpdateMany(ctx context.Context, brands []models.Brand) error
+	// DeleteMany applies Delete to each item in one transaction. If one fails
+	// nothing is deleted and the error is a *BatchError.
+	DeleteMany(ctx context.Context, items []models.BulkDelete) error
+	// Modified returns when the table last changed, deletions included, or
+	// the zero time if it never did.
+	Modified(ctx context.Context) (time.Time, error)
+}
+
+// gormBrandRepository is the GORM-backed BrandRepository.
+type gormBrandRepository struct {
+	db *gorm.DB
+}
+
+// NewGormBrandRepository returns a BrandRepository backed by db.
+func NewGormBrandRepository(db *gorm.DB) BrandRepository {
+	return &gormBrandRepository{db: db}
+}
+
+func (r *gormBrandRepository) List(ctx context.Context, page Page) ([]models.Brand, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Brand{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var brands []models.Brand
+	err := r.db.WithContext(ctx).
+		Order("id").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&brands).Error
+
+	return brands, total, err
+}
+
+func (r *gormBrandRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.Brand, error) {
+	var brands []models.Brand
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&brands).Error
+	return brands, err
+}
+
+func (r *gormBrandRepository) FindByID(ctx context.Context, id uint) (*models.Brand, error) {
+	var brand models.Brand
+
+	err := r.db.WithContext(ctx).First(&brand, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &brand, nil
+}
+
+func (r *gormBrandRepository) Create(ctx context.Context, brand *models.Brand) error {
+	brand.Version = 1
+	return r.db.WithContext(ctx).Create(brand).Error
+}
+
+func (r *gormBrandRepository) Update(ctx context.Context, brand *models.Brand) error {
+	return updateVersioned(r.db.WithContext(ctx), brand, brand.ID, &brand.Version)
+}
+
+func (r *gormBrandRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Brand{}, id, version)
+}
+
+func (r *gormBrandRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Brand, error) {
+	var brands []models.Brand
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&brands).Error
+	return brands, err
+}
+
+func (r *gormBrandRepository) CreateMany(ctx context.Context, brands []models.Brand) error {
+	for i := range brands {
+		brands[i].Version = 1
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&brands, createBatchSize).Error
+	})
+}
+
+func (r *gormBrandRepository) UpdateMany(ctx context.Context, brands []models.Brand) error {
+	return eachInTransaction(r.db.WithContext(ctx), len(brands), func(tx *gorm.DB, i int) error {
+		return updateVersioned(tx, &brands[i], brands[i].ID, &brands[i].Version)
+	})
+}
+
+func (r *gormBrandRepository) DeleteMany(ctx context.Context, items []models.BulkDelete) error {
+	return deleteManyVersioned(r.db.WithContext(ctx), &models.Brand{}, items)
+}
+
+func (r *gormBrandRepository) FindByNames(ctx context.Context, names []string) ([]models.Brand, error) {
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+
+	var brands []models.Brand
+	err := r.db.WithContext(ctx).Where("LOWER(name) IN ?", lowered).Order("id").Find(&brands).Error
+	return brands, err
+}
+
+func (r *gormBrandRepository) Modified(ctx context.Context) (time.Time, error) {
+	return lastModified(r.db.WithContext(ctx), &models.Brand{})
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// CategoryRepository defines persistence operations for categories.
+type CategoryRepository interface {
+	// List returns the requested page ordered by ID together with the total count.
+	List(ctx context.Context, page Page) ([]models.Category, int64, error)
+	// ListAfter returns up to limit categories whose ID is above afterID, ordered
+	// by ID, so that a whole table can be walked without offsets.
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]models.Category, error)
+	FindByID(ctx context.Context, id uint) (*models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	// Update saves category if the stored row is still at category.Version and
+	// increments it; otherwise it returns ErrVersionConflict.
+	Update(ctx context.Context, category *models.Category) error
+	// Delete removes the record if it is still at version (0: any version).
+	Delete(ctx context.Context, id, version uint) error
+	// FindByIDs returns the categories among ids that exist, ordered by ID.
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Category, error)
+	// FindByTitles returns the categories whose title matches one of titles, ignoring
+	// case, ordered by ID.
+	FindByTitles(ctx context.Context, titles []string) ([]models.Category, error)
+	// CreateMany inserts categories in one transaction: all of them or none.
+	CreateMany(ctx context.Context, categories []models.Category) error
+	// UpdateMany applies Update to each of categories in one transaction. If one
+	// fails nothing is saved and the error is a *BatchError.
+	UpdateMany(ctx context.Context, categories []models.Category) error
+	// DeleteMany applies Delete to each item in one transaction. If one fails
+	// nothing is deleted and the error is a *BatchError.
+	DeleteMany(ctx context.Context, items []models.BulkDelete) error
+	// Modified returns when the table last changed, deletions included, or
+	// the zero time if it never did.
+	Modified(ctx context.Context) (time.Time, error)
+}
+
+// gormCategoryRepository is the GORM-backed CategoryRepository.
+type gormCategoryRepository struct {
+	db *gorm.DB
+}
+
+// NewGormCategoryRepository returns a CategoryRepository backed by db.
+func NewGormCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &gormCategoryRepository{db: db}
+}
+
+func (r *gormCategoryRepository) List(ctx context.Context, page Page) ([]models.Category, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Category{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var categories []models.Category
+	err := r.db.WithContext(ctx).
+		Order("id").
+		Limit(page.Limit).
+		Offset(page.Offset).
+		Find(&categories).Error
+
+	return categories, total, err
+}
+
+func (r *gormCategoryRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) FindByID(ctx context.Context, id uint) (*models.Category, error) {
+	var category models.Category
+
+	err := r.db.WithContext(ctx).First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
+}
+
+func (r *gormCategoryRepository) Create(ctx context.Context, category *models.Category) error {
+	category.Version = 1
+	return r.db.WithContext(ctx).Create(category).Error
+}
+
+func (r *gormCategoryRepository) Update(ctx context.Context, category *models.Category) error {
+	return updateVersioned(r.db.WithContext(ctx), category, category.ID, &category.Version)
+}
+
+func (r *gormCategoryRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Category{}, id, version)
+}
+
+func (r *gormCategoryRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) CreateMany(ctx context.Context, categories []models.Category) error {
+	for i := range categories {
+		categories[i].Version = 1
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&categories, createBatchSize).Error
+	})
+}
+
+func (r *gormCategoryRepository) UpdateMany(ctx context.Context, categories []models.Category) error {
+	return eachInTransaction(r.db.WithContext(ctx), len(categories), func(tx *gorm.DB, i int) error {
+		return updateVersioned(tx, &categories[i], categories[i].ID, &categories[i].Version)
+	})
+}
+
+func (r *gormCategoryRepository) DeleteMany(ctx context.Context, items []models.BulkDelete) error {
+	return deleteManyVersioned(r.db.WithContext(ctx), &models.Category{}, items)
+}
+
+func (r *gormCategoryRepository) FindByTitles(ctx context.Context, titles []string) ([]models.Category, error) {
+	lowered := make([]string, len(titles))
+	for i, title := range titles {
+		lowered[i] = strings.ToLower(title)
+	}
+
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Where("LOWER(title) IN ?", lowered).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+func (r *gormCategoryRepository) Modified(ctx context.Context) (time.Time, error) {
+	return lastModified(r.db.WithContext(ctx), &models.Category{})
+}
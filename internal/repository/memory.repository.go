@@ -0,0 +1,852 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Compile-time checks that the in-memory repositories satisfy their interfaces.
+var (
+	_ ProductRepository  = (*MemoryProductRepository)(nil)
+	_ CategoryRepository = (*MemoryCategoryRepository)(nil)
+	_ BrandRepository    = (*MemoryBrandRepository)(nil)
+	_ APIKeyRepository   = (*MemoryAPIKeyRepository)(nil)
+)
+
+// MemoryBrandRepository is an in-memory BrandRepository, safe for concurrent use.
+// It is intended for tests and local experiments.
+type MemoryBrandRepository struct {
+	mu        sync.RWMutex
+	nextID    uint
+	items     map[uint]models.Brand
+	deletedAt time.Time // last Delete or DeleteMany
+}
+
+// NewMemoryBrandRepository returns an empty MemoryBrandRepository.
+func NewMemoryBrandRepository() *MemoryBrandRepository {
+	return &MemoryBrandRepository{items: map[uint]models.Brand{}}
+}
+
+func (r *MemoryBrandRepository) List(_ context.Context, page Page) ([]models.Brand, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := sortedKeys(r.items)
+	brands := make([]models.Brand, 0, page.Limit)
+	for i := page.Offset; i < len(ids) && len(brands) < page.Limit; i++ {
+		brands = append(brands, r.items[ids[i]])
+	}
+	return brands, int64(len(ids)), nil
+}
+
+func (r *MemoryBrandRepository) ListAfter(_ context.Context, afterID uint, limit int) ([]models.Brand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	brands := make([]models.Brand, 0, limit)
+	for _, id := range sortedKeys(r.items) {
+		if len(brands) == limit {
+			break
+		}
+		if id > afterID {
+			brands = append(brands, r.items[id])
+		}
+	}
+	return brands, nil
+}
+
+func (r *MemoryBrandRepository) FindByID(_ context.Context, id uint) (*models.Brand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	brand, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &brand, nil
+}
+
+func (r *MemoryBrandRepository) Create(_ context.Context, brand *models.Brand) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	brand.ID = r.nextID
+	brand.Version = 1
+	brand.CreatedAt = now
+	brand.UpdatedAt = now
+	r.items[brand.ID] = *brand
+	return nil
+}
+
+func (r *MemoryBrandRepository) Update(_ context.Context, brand *models.Brand) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[brand.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != brand.Version {
+		return ErrVersionConflict
+	}
+	brand.Version++
+	brand.UpdatedAt = time.Now()
+	r.items[brand.ID] = *brand
+	return nil
+}
+
+func (r *MemoryBrandRepository) Delete(_ context.Context, id, version uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && stored.Version != version {
+		return ErrVersionConflict
+	}
+	delete(r.items, id)
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryBrandRepository) FindByIDs(_ context.Context, ids []uint) ([]models.Brand, error) {
+	r.mu.RLock()
+	brands := pickSorted(r.items, ids)
+	r.mu.RUnlock()
+
+	return brands, nil
+}
+
+func (r *MemoryBrandRepository) FindByNames(_ context.Context, names []string) ([]models.Brand, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var brands []models.Brand
+	for _, id := range sortedKeys(r.items) {
+		record := r.items[id]
+		if slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, record.Name) }) {
+			brands = append(brands, record)
+		}
+	}
+	return brands, nil
+}
+
+func (r *MemoryBrandRepository) CreateMany(_ context.Context, brands []models.Brand) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range brands {
+		r.nextID++
+		brands[i].ID = r.nextID
+		brands[i].Version = 1
+		brands[i].CreatedAt = now
+		brands[i].UpdatedAt = now
+		r.items[r.nextID] = brands[i]
+	}
+	return nil
+}
+
+func (r *MemoryBrandRepository) UpdateMany(_ context.Context, brands []models.Brand) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Work on a copy so a failure part way leaves the stored records untouched
+	items := maps.Clone(r.items)
+	now := time.Now()
+	for i := range brands {
+		stored, ok := items[brands[i].ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case stored.Version != brands[i].Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		brands[i].Version++
+		brands[i].UpdatedAt = now
+		items[brands[i].ID] = brands[i]
+	}
+	r.items = items
+	return nil
+}
+
+func (r *MemoryBrandRepository) DeleteMany(_ context.Context, deletes []models.BulkDelete) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := maps.Clone(r.items)
+	for i, item := range deletes {
+		stored, ok := items[item.ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case item.Version != 0 && stored.Version != item.Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		delete(items, item.ID)
+	}
+	r.items = items
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryBrandRepository) Modified(_ context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modified := r.deletedAt
+	for _, record := range r.items {
+		if record.UpdatedAt.After(modified) {
+			modified = record.UpdatedAt
+		}
+	}
+	return modified, nil
+}
+
+// MemoryCategoryRepository is an in-memory CategoryRepository, safe for concurrent use.
+// It is intended for tests and local experiments.
+type MemoryCategoryRepository struct {
+	mu        sync.RWMutex
+	nextID    uint
+	items     map[uint]models.Category
+	deletedAt time.Time // last Delete or DeleteMany
+}
+
+// NewMemoryCategoryRepository returns an empty MemoryCategoryRepository.
+func NewMemoryCategoryRepository() *MemoryCategoryRepository {
+	return &MemoryCategoryRepository{items: map[uint]models.Category{}}
+}
+
+func (r *MemoryCategoryRepository) List(_ context.Context, page Page) ([]models.Category, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := sortedKeys(r.items)
+	categories := make([]models.Category, 0, page.Limit)
+	for i := page.Offset; i < len(ids) && len(categories) < page.Limit; i++ {
+		categories = append(categories, r.items[ids[i]])
+	}
+	return categories, int64(len(ids)), nil
+}
+
+func (r *MemoryCategoryRepository) ListAfter(_ context.Context, afterID uint, limit int) ([]models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]models.Category, 0, limit)
+	for _, id := range sortedKeys(r.items) {
+		if len(categories) == limit {
+			break
+		}
+		if id > afterID {
+			categories = append(categories, r.items[id])
+		}
+	}
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) FindByID(_ context.Context, id uint) (*models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &category, nil
+}
+
+func (r *MemoryCategoryRepository) Create(_ context.Context, category *models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	category.ID = r.nextID
+	category.Version = 1
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	r.items[category.ID] = *category
+	return nil
+}
+
+func (r *MemoryCategoryRepository) Update(_ context.Context, category *models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[category.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != category.Version {
+		return ErrVersionConflict
+	}
+	category.Version++
+	category.UpdatedAt = time.Now()
+	r.items[category.ID] = *category
+	return nil
+}
+
+func (r *MemoryCategoryRepository) Delete(_ context.Context, id, version uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && stored.Version != version {
+		return ErrVersionConflict
+	}
+	delete(r.items, id)
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryCategoryRepository) FindByIDs(_ context.Context, ids []uint) ([]models.Category, error) {
+	r.mu.RLock()
+	categories := pickSorted(r.items, ids)
+	r.mu.RUnlock()
+
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) FindByTitles(_ context.Context, titles []string) ([]models.Category, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var categories []models.Category
+	for _, id := range sortedKeys(r.items) {
+		record := r.items[id]
+		if slices.ContainsFunc(titles, func(title string) bool { return strings.EqualFold(title, record.Title) }) {
+			categories = append(categories, record)
+		}
+	}
+	return categories, nil
+}
+
+func (r *MemoryCategoryRepository) CreateMany(_ context.Context, categories []models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range categories {
+		r.nextID++
+		categories[i].ID = r.nextID
+		categories[i].Version = 1
+		categories[i].CreatedAt = now
+		categories[i].UpdatedAt = now
+		r.items[r.nextID] = categories[i]
+	}
+	return nil
+}
+
+func (r *MemoryCategoryRepository) UpdateMany(_ context.Context, categories []models.Category) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Work on a copy so a failure part way leaves the stored records untouched
+	items := maps.Clone(r.items)
+	now := time.Now()
+	for i := range categories {
+		stored, ok := items[categories[i].ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case stored.Version != categories[i].Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		categories[i].Version++
+		categories[i].UpdatedAt = now
+		items[categories[i].ID] = categories[i]
+	}
+	r.items = items
+	return nil
+}
+
+func (r *MemoryCategoryRepository) DeleteMany(_ context.Context, deletes []models.BulkDelete) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := maps.Clone(r.items)
+	for i, item := range deletes {
+		stored, ok := items[item.ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case item.Version != 0 && stored.Version != item.Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		delete(items, item.ID)
+	}
+	r.items = items
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryCategoryRepository) Modified(_ context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modified := r.deletedAt
+	for _, record := range r.items {
+		if record.UpdatedAt.After(modified) {
+			modified = record.UpdatedAt
+		}
+	}
+	return modified, nil
+}
+
+// MemoryProductRepository is an in-memory ProductRepository, safe for concurrent use.
+// Category and Brand are resolved from the given repositories on read, mirroring
+// the Preload behaviour of the GORM implementation.
+type MemoryProductRepository struct {
+	mu         sync.RWMutex
+	nextID     uint
+	items      map[uint]models.Product
+	deletedAt  time.Time // last Delete or DeleteMany
+	categories CategoryRepository
+	brands     BrandRepository
+}
+
+// NewMemoryProductRepository returns an empty MemoryProductRepository that
+// resolves relations through categories and brands.
+func NewMemoryProductRepository(categories CategoryRepository, brands BrandRepository) *MemoryProductRepository {
+	return &MemoryProductRepository{
+		items:      map[uint]models.Product{},
+		categories: categories,
+		brands:     brands,
+	}
+}
+
+func (r *MemoryProductRepository) List(ctx context.Context, query ProductQuery) ([]models.Product, int64, error) {
+	r.mu.RLock()
+	matched := make([]models.Product, 0, len(r.items))
+	for _, id := range sortedKeys(r.items) {
+		if product := r.items[id]; matchProduct(product, query.Filter) {
+			matched = append(matched, product)
+		}
+	}
+	r.mu.RUnlock()
+
+	sortProductSlice(matched, query.Sort)
+
+	products := make([]models.Product, 0, query.Limit)
+	for i := query.Offset; i < len(matched) && len(products) < query.Limit; i++ {
+		products = append(products, matched[i])
+	}
+
+	for i := range products {
+		r.preload(ctx, &products[i])
+	}
+	return products, int64(len(matched)), nil
+}
+
+func (r *MemoryProductRepository) ListKeyset(ctx context.Context, query ProductQuery, keyset *Keyset) ([]models.Product, error) {
+	r.mu.RLock()
+	matched := make([]models.Product, 0, len(r.items))
+	for _, id := range sortedKeys(r.items) {
+		if product := r.items[id]; matchProduct(product, query.Filter) {
+			matched = append(matched, product)
+		}
+	}
+	r.mu.RUnlock()
+
+	keys := NormalizeProductSort(query.Sort)
+	backward := keyset != nil && keyset.Backward
+	if backward {
+		keys = reverseSort(keys)
+	}
+	sortProductSlice(matched, keys)
+
+	products := make([]models.Product, 0, query.Limit)
+	for _, product := range matched {
+		if len(products) == query.Limit {
+			break
+		}
+		if keyset != nil && compareProductKeys(product, keyset.Boundary, keys) <= 0 {
+			continue
+		}
+		products = append(products, product)
+	}
+
+	if backward {
+		slices.Reverse(products)
+	}
+	for i := range products {
+		r.preload(ctx, &products[i])
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) FindByID(ctx context.Context, id uint) (*models.Product, error) {
+	r.mu.RLock()
+	product, ok := r.items[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+	r.preload(ctx, &product)
+	return &product, nil
+}
+
+func (r *MemoryProductRepository) Create(_ context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	product.ID = r.nextID
+	product.Version = 1
+	product.CreatedAt = now
+	product.UpdatedAt = now
+	r.items[product.ID] = stripRelations(*product)
+	return nil
+}
+
+func (r *MemoryProductRepository) Update(_ context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[product.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != product.Version {
+		return ErrVersionConflict
+	}
+	product.Version++
+	product.UpdatedAt = time.Now()
+	r.items[product.ID] = stripRelations(*product)
+	return nil
+}
+
+func (r *MemoryProductRepository) Delete(_ context.Context, id, version uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != 0 && stored.Version != version {
+		return ErrVersionConflict
+	}
+	delete(r.items, id)
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryProductRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Product, error) {
+	r.mu.RLock()
+	products := pickSorted(r.items, ids)
+	r.mu.RUnlock()
+
+	for i := range products {
+		r.preload(ctx, &products[i])
+	}
+	return products, nil
+}
+
+func (r *MemoryProductRepository) CreateMany(_ context.Context, products []models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i := range products {
+		r.nextID++
+		products[i].ID = r.nextID
+		products[i].Version = 1
+		products[i].CreatedAt = now
+		products[i].UpdatedAt = now
+		r.items[r.nextID] = stripRelations(products[i])
+	}
+	return nil
+}
+
+func (r *MemoryProductRepository) UpdateMany(_ context.Context, products []models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Work on a copy so a failure part way leaves the stored records untouched
+	items := maps.Clone(r.items)
+	now := time.Now()
+	for i := range products {
+		stored, ok := items[products[i].ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case stored.Version != products[i].Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		products[i].Version++
+		products[i].UpdatedAt = now
+		items[products[i].ID] = stripRelations(products[i])
+	}
+	r.items = items
+	return nil
+}
+
+func (r *MemoryProductRepository) DeleteMany(_ context.Context, deletes []models.BulkDelete) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	items := maps.Clone(r.items)
+	for i, item := range deletes {
+		stored, ok := items[item.ID]
+		switch {
+		case !ok:
+			return &BatchError{Index: i, Err: ErrNotFound}
+		case item.Version != 0 && stored.Version != item.Version:
+			return &BatchError{Index: i, Err: ErrVersionConflict}
+		}
+		delete(items, item.ID)
+	}
+	r.items = items
+	r.deletedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryProductRepository) Modified(_ context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	modified := r.deletedAt
+	for _, record := range r.items {
+		if record.UpdatedAt.After(modified) {
+			modified = record.UpdatedAt
+		}
+	}
+	return modified, nil
+}
+
+// Search ranks the matching products the way the GORM implementation does
+// on SQLite without FTS5.
+func (r *MemoryProductRepository) Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, int64, error) {
+	hits := []models.SearchHit{}
+	if len(query.Terms) == 0 {
+		return hits, 0, nil
+	}
+
+	r.mu.RLock()
+	for _, id := range sortedKeys(r.items) {
+		product := r.items[id]
+		if !matchProduct(product, query.Filter) {
+			continue
+		}
+		if score := matchScore(product, query.Terms); score > 0 {
+			hits = append(hits, models.SearchHit{Product: product, Score: score})
+		}
+	}
+	r.mu.RUnlock()
+
+	// Keys are visited in ID order, so a stable sort breaks ties by ID
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	total := int64(len(hits))
+	hits = hits[min(query.Offset, len(hits)):min(query.Offset+query.Limit, len(hits))]
+
+	for i := range hits {
+		r.preload(ctx, &hits[i].Product)
+		hits[i].Highlights = highlightProduct(hits[i].Product, query.Terms)
+	}
+	return hits, total, nil
+}
+
+// MemoryAPIKeyRepository is an in-memory APIKeyRepository, safe for concurrent use.
+// It is intended for tests and local experiments.
+type MemoryAPIKeyRepository struct {
+	mu     sync.RWMutex
+	nextID uint
+	items  map[uint]models.APIKey
+}
+
+// NewMemoryAPIKeyRepository returns an empty MemoryAPIKeyRepository.
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{items: map[uint]models.APIKey{}}
+}
+
+func (r *MemoryAPIKeyRepository) List(_ context.Context, page Page) ([]models.APIKey, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := sortedKeys(r.items)
+	keys := make([]models.APIKey, 0, page.Limit)
+	for i := page.Offset; i < len(ids) && len(keys) < page.Limit; i++ {
+		keys = append(keys, r.items[ids[i]])
+	}
+	return keys, int64(len(ids)), nil
+}
+
+func (r *MemoryAPIKeyRepository) FindByID(_ context.Context, id uint) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.items[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &key, nil
+}
+
+func (r *MemoryAPIKeyRepository) FindByPrefix(_ context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.items {
+		if key.Prefix == prefix {
+			return &key, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *MemoryAPIKeyRepository) Create(_ context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	now := time.Now()
+	key.ID = r.nextID
+	key.CreatedAt = now
+	key.UpdatedAt = now
+	key.Scopes = slices.Clone(key.Scopes)
+	r.items[key.ID] = *key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Revoke(_ context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		r.items[id] = key
+	}
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) Touch(_ context.Context, id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.items[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	r.items[id] = key
+	return nil
+}
+
+// preload fills in Category and Brand, leaving them zero-valued when missing.
+func (r *MemoryProductRepository) preload(ctx context.Context, product *models.Product) {
+	if category, err := r.categories.FindByID(ctx, product.CategoryID); err == nil {
+		product.Category = *category
+	}
+	if brand, err := r.brands.FindByID(ctx, product.BrandID); err == nil {
+		product.Brand = *brand
+	}
+}
+
+// matchProduct reports whether product satisfies every set field of f.
+func matchProduct(product models.Product, f ProductFilter) bool {
+	switch {
+	case f.CategoryID != nil && product.CategoryID != *f.CategoryID:
+		return false
+	case f.BrandID != nil && product.BrandID != *f.BrandID:
+		return false
+	case f.MinPrice != nil && product.Price < *f.MinPrice:
+		return false
+	case f.MaxPrice != nil && product.Price > *f.MaxPrice:
+		return false
+	case f.Name != "" && !strings.Contains(strings.ToLower(product.Name), strings.ToLower(f.Name)):
+		return false
+	}
+	return true
+}
+
+// sortProductSlice orders products the same way sortProducts orders rows.
+func sortProductSlice(products []models.Product, fields []SortField) {
+	keys := NormalizeProductSort(fields)
+	sort.SliceStable(products, func(i, j int) bool {
+		return compareProductKeys(products[i], products[j], keys) < 0
+	})
+}
+
+// compareProductKeys compares a and b by every key in order, honouring direction.
+func compareProductKeys(a, b models.Product, keys []SortField) int {
+	for _, key := range keys {
+		c := compareProducts(a, b, key.Field)
+		if c == 0 {
+			continue
+		}
+		if key.Desc {
+			return -c
+		}
+		return c
+	}
+	return 0
+}
+
+// compareProducts compares a and b on a single sort field.
+func compareProducts(a, b models.Product, field string) int {
+	switch field {
+	case "name":
+		return strings.Compare(a.Name, b.Name)
+	case "price":
+		return cmp.Compare(a.Price, b.Price)
+	case "created_at":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	default:
+		return cmp.Compare(a.ID, b.ID)
+	}
+}
+
+// stripRelations drops embedded associations so only foreign keys are stored.
+func stripRelations(product models.Product) models.Product {
+	product.Category = models.Category{}
+	product.Brand = models.Brand{}
+	return product
+}
+
+// pickSorted returns the records among ids that exist in items, ordered by ID.
+func pickSorted[T any](items map[uint]T, ids []uint) []T {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	picked := make([]T, 0, len(ids))
+	for i, id := range ids {
+		if record, ok := items[id]; ok && (i == 0 || id != ids[i-1]) {
+			picked = append(picked, record)
+		}
+	}
+	return picked
+}
+
+// sortedKeys returns the map keys in ascending order, matching the primary-key
+// ordering the SQL implementations return by default.
+func sortedKeys[T any](items map[uint]T) []uint {
+	keys := make([]uint, 0, len(items))
+	for id := range items {
+		keys = append(keys, id)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProductSortFields whitelists the fields a product listing may be sorted by,
+// mapped to their column names.
+var ProductSortFields = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"price":      "price",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// ProductFilter narrows a product listing. Nil and empty fields are ignored.
+type ProductFilter struct {
+	CategoryID *uint
+	BrandID    *uint
+	MinPrice   *float64
+	MaxPrice   *float64
+	Name       string // case-insensitive substring match
+}
+
+// SortField orders a listing by one of the whitelisted fields.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ProductQuery describes a page of products.
+type ProductQuery struct {
+	Page
+	Filter ProductFilter
+	Sort   []SortField
+}
+
+// Keyset positions a product listing relative to a boundary row instead of an
+// offset. Only the ID and the fields named by the active sort are read from
+// Boundary.
+type Keyset struct {
+	Boundary models.Product
+	Backward bool // return the rows just before Boundary instead of after it
+}
+
+// ProductRepository defines persistence operations for products.
+type ProductRepository interface {
+	// List returns the requested page together with the total number of
+	// products matching the filter.
+	List(ctx context.Context, query ProductQuery) ([]models.Product, int64, error)
+	// ListKeyset returns up to query.Limit products following (or preceding)
+	// the keyset boundary in query order; query.Offset is ignored. A nil
+	// keyset starts from the beginning.
+	ListKeyset(ctx context.Context, query ProductQuery, keyset *Keyset) ([]models.Product, error)
+	FindByID(ctx context.Context, id uint) (*models.Product, error)
+	Create(ctx context.Context, product *models.Product) error
+	// Update saves product if the stored row is still at product.Version and
+	// increments it; otherwise it returns ErrVersionConflict.
+	Update(ctx context.Context, product *models.Product) error
+	// Delete removes the record if it is still at version (0: any version).
+	Delete(ctx context.Context, id, version uint) error
+	// FindByIDs returns the products among ids that exist, ordered by ID.
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Product, error)
+	// CreateMany inserts products in one transaction: all of them or none.
+	CreateMany(ctx context.Context, products []models.Product) error
+	// UpdateMany applies Update to each of products in one transaction. If one
+	// fails nothing is saved and the error is a *BatchError.
+	UpdateMany(ctx context.Context, products []models.Product) error
+	// DeleteMany applies Delete to each item in one transaction. If one fails
+	// nothing is deleted and the error is a *BatchError.
+	DeleteMany(ctx context.Context, items []models.BulkDelete) error
+	// Modified returns when the table last changed, deletions included, or
+	// the zero time if it never did.
+	Modified(ctx context.Context) (time.Time, error)
+	// Search returns the requested page of products matching query, most
+	// relevant first, together with the number of matches.
+	Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, int64, error)
+}
+
+// gormProductRepository is the GORM-backed ProductRepository.
+type gormProductRepository struct {
+	db *gorm.DB
+
+	searchOnce sync.Once
+	search     searchEngine // picked by engine
+}
+
+// NewGormProductRepository returns a ProductRepository backed by db.
+func NewGormProductRepository(db *gorm.DB) ProductRepository {
+	return &gormProductRepository{db: db}
+}
+
+func (r *gormProductRepository) List(ctx context.Context, query ProductQuery) ([]models.Product, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).
+		Model(&models.Product{}).
+		Scopes(filterProducts(query.Filter)).
+		Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var products []models.Product
+
+	// Query products with related Category and Brand using GORM Preload
+	err := r.db.WithContext(ctx).
+		Scopes(filterProducts(query.Filter), sortProducts(query.Sort)).
+		Preload("Category").
+		Preload("Brand").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Find(&products).Error
+
+	return products, total, err
+}
+
+func (r *gormProductRepository) ListKeyset(ctx context.Context, query ProductQuery, keyset *Keyset) ([]models.Product, error) {
+	keys := NormalizeProductSort(query.Sort)
+	backward := keyset != nil && keyset.Backward
+	if backward {
+		// Walk towards the start, then flip the page back into query order
+		keys = reverseSort(keys)
+	}
+
+	db := r.db.WithContext(ctx).
+		Scopes(filterProducts(query.Filter), sortProducts(keys)).
+		Preload("Category").
+		Preload("Brand").
+		Limit(query.Limit)
+	if keyset != nil {
+		db = db.Scopes(seekProducts(keys, keyset.Boundary))
+	}
+
+	var products []models.Product
+	if err := db.Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	if backward {
+		slices.Reverse(products)
+	}
+	return products, nil
+}
+
+func (r *gormProductRepository) FindByID(ctx context.Context, id uint) (*models.Product, error) {
+	var product models.Product
+
+	err := r.db.WithContext(ctx).Preload("Category").Preload("Brand").First(&product, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+func (r *gormProductRepository) Create(ctx context.Context, product *models.Product) error {
+	// Associations are referenced by ID only; never upsert a Category/Brand sent in the body
+	product.Version = 1
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(product).Error
+}
+
+func (r *gormProductRepository) Update(ctx context.Context, product *models.Product) error {
+	// Associations are omitted so a stale preloaded Category/Brand is never written back
+	return updateVersioned(r.db.WithContext(ctx), product, product.ID, &product.Version)
+}
+
+func (r *gormProductRepository) Delete(ctx context.Context, id, version uint) error {
+	return deleteVersioned(r.db.WithContext(ctx), &models.Product{}, id, version)
+}
+
+// filterProducts translates a ProductFilter into WHERE clauses.
+func filterProducts(f ProductFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if f.CategoryID != nil {
+			db = db.Where("category_id = ?", *f.CategoryID)
+		}
+		if f.BrandID != nil {
+			db = db.Where("brand_id = ?", *f.BrandID)
+		}
+		if f.MinPrice != nil {
+			db = db.Where("price >= ?", *f.MinPrice)
+		}
+		if f.MaxPrice != nil {
+			db = db.Where("price <= ?", *f.MaxPrice)
+		}
+		if f.Name != "" {
+			// '!' is used as the LIKE escape character because it needs no
+			// quoting in any of the supported dialects
+			pattern := "%" + likeEscaper.Replace(strings.ToLower(f.Name)) + "%"
+			db = db.Where("LOWER(name) LIKE ? ESCAPE '!'", pattern)
+		}
+		return db
+	}
+}
+
+// NormalizeProductSort drops unknown fields and guarantees the primary key is
+// the last sort key, so every ordering is total and pages are stable.
+func NormalizeProductSort(fields []SortField) []SortField {
+	out := make([]SortField, 0, len(fields)+1)
+	for _, f := range fields {
+		if _, ok := ProductSortFields[f.Field]; !ok {
+			continue
+		}
+		out = append(out, f)
+		if f.Field == "id" {
+			// Keys after a unique column can never change the order
+			return out
+		}
+	}
+	return append(out, SortField{Field: "id"})
+}
+
+// sortProducts translates sort fields into ORDER BY clauses.
+func sortProducts(fields []SortField) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, f := range NormalizeProductSort(fields) {
+			column := ProductSortFields[f.Field]
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: f.Desc})
+		}
+		return db
+	}
+}
+
+// seekProducts restricts rows to those strictly after boundary in the given
+// (already normalized) order, expanding the row comparison into
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... so mixed directions work in
+// every dialect.
+func seekProducts(keys []SortField, boundary models.Product) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		var (
+			disjuncts []string
+			args      []interface{}
+		)
+		for i, key := range keys {
+			var conj []string
+			for _, prev := range keys[:i] {
+				conj = append(conj, ProductSortFields[prev.Field]+" = ?")
+				args = append(args, productSortValue(boundary, prev.Field))
+			}
+			op := " > ?"
+			if key.Desc {
+				op = " < ?"
+			}
+			conj = append(conj, ProductSortFields[key.Field]+op)
+			args = append(args, productSortValue(boundary, key.Field))
+			disjuncts = append(disjuncts, "("+strings.Join(conj, " AND ")+")")
+		}
+		return db.Where(strings.Join(disjuncts, " OR "), args...)
+	}
+}
+
+// productSortValue returns the value of a whitelisted sort field.
+func productSortValue(p models.Product, field string) interface{} {
+	switch field {
+	case "name":
+		return p.Name
+	case "price":
+		return p.Price
+	case "created_at":
+		return p.CreatedAt
+	case "updated_at":
+		return p.UpdatedAt
+	default:
+		return p.ID
+	}
+}
+
+// reverseSort flips every direction of an ordering.
+func reverseSort(fields []SortField) []SortField {
+	out := make([]SortField, len(fields))
+	for i, f := range fields {
+		out[i] = SortField{Field: f.Field, Desc: !f.Desc}
+	}
+	return out
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (r *gormProductRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Product, error) {
+	var products []models.Product
+	err := r.db.WithContext(ctx).Preload("Category").Preload("Brand").Where("id IN ?", ids).Order("id").Find(&products).Error
+	return products, err
+}
+
+func (r *gormProductRepository) CreateMany(ctx context.Context, products []models.Product) error {
+	for i := range products {
+		products[i].Version = 1
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return tx.Omit(clause.Associations).CreateInBatches(&products, createBatchSize).Error
+	})
+}
+
+func (r *gormProductRepository) UpdateMany(ctx context.Context, products []models.Product) error {
+	return eachInTransaction(r.db.WithContext(ctx), len(products), func(tx *gorm.DB, i int) error {
+		return updateVersioned(tx, &products[i], products[i].ID, &products[i].Version)
+	})
+}
+
+func (r *gormProductRepository) DeleteMany(ctx context.Context, items []models.BulkDelete) error {
+	return deleteManyVersioned(r.db.WithContext(ctx), &models.Product{}, items)
+}
+
+func (r *gormProductRepository) Modified(ctx context.Context) (time.Time, error) {
+	return lastModified(r.db.WithContext(ctx), &models.Product{})
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// ErrNotFound is returned by every repository when the requested record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// ErrVersionConflict is returned by Update and Delete when the record has
+// moved past the version the caller read.
+var ErrVersionConflict = errors.New("version conflict")
+
+// BatchError reports the item that made a batch write fail. The whole batch
+// was rolled back.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// createBatchSize is the number of rows per INSERT statement in CreateMany.
+const createBatchSize = 100
+
+// Page selects a window of an ordered listing.
+type Page struct {
+	Offset int
+	Limit  int
+}
+
+// updateVersioned writes every column of model except id and created_at, but
+// only while the stored row is still at *version, which it then bumps. model
+// must be a pointer to a record with its ID set. Associations are never
+// written.
+func updateVersioned(db *gorm.DB, model any, id uint, version *uint) error {
+	expected := *version
+	*version = expected + 1
+	res := db.Model(model).
+		Select("*").
+		Omit("id", "created_at", clause.Associations).
+		Where("version = ?", expected).
+		Updates(model)
+	if res.Error == nil && res.RowsAffected == 1 {
+		return nil
+	}
+
+	*version = expected
+	if res.Error != nil {
+		return res.Error
+	}
+	return missingOrConflict(db, model, id)
+}
+
+// deleteVersioned deletes the record id of model's type if it is still at
+// version; version 0 deletes it whatever its version. It stamps the table's
+// deletion time in the same transaction.
+func deleteVersioned(db *gorm.DB, model any, id, version uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteRow(tx, model, id, version); err != nil {
+			return err
+		}
+		return stampDeletion(tx, model)
+	})
+}
+
+// deleteManyVersioned deletes each of items like deleteVersioned, in one
+// transaction that is rolled back at the first failure, which is returned as
+// a *BatchError.
+func deleteManyVersioned(db *gorm.DB, model any, items []models.BulkDelete) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range items {
+			if err := deleteRow(tx, model, item.ID, item.Version); err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+		}
+		return stampDeletion(tx, model)
+	})
+}
+
+func deleteRow(db *gorm.DB, model any, id, version uint) error {
+	query := db
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+	res := query.Delete(model, id)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return missingOrConflict(db, model, id)
+	}
+	return nil
+}
+
+// stampDeletion records now as the last deletion from model's table.
+func stampDeletion(db *gorm.DB, model any) error {
+	table, err := tableName(db, model)
+	if err != nil {
+		return err
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "table_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_deleted_at"}),
+	}).Create(&models.TableDeletion{Table: table, LastDeletedAt: db.NowFunc()}).Error
+}
+
+// lastModified is when model's table last changed: its newest updated_at, or
+// its last deletion if that came later. It is the zero time for a table that
+// was never written.
+func lastModified(db *gorm.DB, model any) (time.Time, error) {
+	table, err := tableName(db, model)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Plucked rather than MAX()ed: SQLite returns an aggregate as text
+	var updated, deleted []time.Time
+	if err := db.Model(model).
+		Where("updated_at IS NOT NULL").
+		Order("updated_at DESC").
+		Limit(1).
+		Pluck("updated_at", &updated).Error; err != nil {
+		return time.Time{}, err
+	}
+	if err := db.Model(&models.TableDeletion{}).
+		Where("table_name = ?", table).
+		Pluck("last_deleted_at", &deleted).Error; err != nil {
+		return time.Time{}, err
+	}
+
+	var modified time.Time
+	for _, t := range append(updated, deleted...) {
+		if t.After(modified) {
+			modified = t
+		}
+	}
+	return modified, nil
+}
+
+// tableName returns the table GORM maps model to.
+func tableName(db *gorm.DB, model any) (string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", err
+	}
+	return stmt.Schema.Table, nil
+}
+
+// missingOrConflict explains why a versioned write matched no row.
+func missingOrConflict(db *gorm.DB, model any, id uint) error {
+	var count int64
+	if err := db.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// eachInTransaction calls write for items 0..n-1 inside one transaction,
+// rolling it back at the first failure, which is returned as a *BatchError.
+func eachInTransaction(db *gorm.DB, n int, write func(tx *gorm.DB, i int) error) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for i := 0; i < n; i++ {
+			if err := write(tx, i); err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/migrations"
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// catalog is one implementation of the catalog repositories under test.
+type catalog struct {
+	products   ProductRepository
+	categories CategoryRepository
+	brands     BrandRepository
+	apiKeys    APIKeyRepository
+}
+
+// implementations returns a fresh in-memory catalog and a fresh GORM catalog
+// on a migrated SQLite file, so every test holds both to the same contract.
+func implementations() map[string]func(t *testing.T) catalog {
+	return map[string]func(t *testing.T) catalog{
+		"memory": func(t *testing.T) catalog {
+			categories := NewMemoryCategoryRepository()
+			brands := NewMemoryBrandRepository()
+			return catalog{NewMemoryProductRepository(categories, brands), categories, brands, NewMemoryAPIKeyRepository()}
+		},
+		"gorm": func(t *testing.T) catalog {
+			db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "catalog.db")), &gorm.Config{
+				Logger: logger.Default.LogMode(logger.Silent),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { sqlDB.Close() })
+
+			migrator, err := migrations.New(sqlDB, "sqlite")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := migrator.Up(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			return catalog{NewGormProductRepository(db), NewGormCategoryRepository(db), NewGormBrandRepository(db), NewGormAPIKeyRepository(db)}
+		},
+	}
+}
+
+// seed creates a category, a brand and products priced 10, 20, ... named
+// after names.
+func seed(t *testing.T, cat catalog, names ...string) (models.Category, models.Brand, []models.Product) {
+	t.Helper()
+	ctx := context.Background()
+
+	category := models.Category{Title: "Phones", CoverImage: "https://example.com/phones.png"}
+	if err := cat.categories.Create(ctx, &category); err != nil {
+		t.Fatal(err)
+	}
+	brand := models.Brand{Name: "Acme", CoverImage: "https://example.com/acme.png"}
+	if err := cat.brands.Create(ctx, &brand); err != nil {
+		t.Fatal(err)
+	}
+
+	products := make([]models.Product, len(names))
+	for i, name := range names {
+		products[i] = models.Product{
+			Name:        name,
+			Description: name + " description",
+			Price:       float64(10 * (i + 1)),
+			CoverImage:  "https://example.com/product.png",
+			CategoryID:  category.ID,
+			BrandID:     brand.ID,
+		}
+		if err := cat.products.Create(ctx, &products[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return category, brand, products
+}
+
+func TestBrandVersioning(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			_, brand, _ := seed(t, cat)
+			if brand.ID == 0 || brand.Version != 1 {
+				t.Fatalf("created brand has id %d, version %d; want an id and version 1", brand.ID, brand.Version)
+			}
+
+			stale := brand
+			brand.Name = "Acme Corp"
+			if err := cat.brands.Update(ctx, &brand); err != nil {
+				t.Fatal(err)
+			}
+			if brand.Version != 2 {
+				t.Fatalf("version after update = %d, want 2", brand.Version)
+			}
+
+			stale.Name = "Lost update"
+			if err := cat.brands.Update(ctx, &stale); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("update at a stale version: err = %v, want ErrVersionConflict", err)
+			}
+			if stale.Version != 1 {
+				t.Fatalf("failed update changed the version to %d", stale.Version)
+			}
+			if err := cat.brands.Delete(ctx, brand.ID, 1); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("delete at a stale version: err = %v, want ErrVersionConflict", err)
+			}
+
+			got, err := cat.brands.FindByID(ctx, brand.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "Acme Corp" || got.Version != 2 {
+				t.Fatalf("stored brand = %q version %d, want %q version 2", got.Name, got.Version, "Acme Corp")
+			}
+
+			if err := cat.brands.Delete(ctx, brand.ID, 2); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cat.brands.FindByID(ctx, brand.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("FindByID after delete: err = %v, want ErrNotFound", err)
+			}
+			if err := cat.brands.Update(ctx, &brand); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("update after delete: err = %v, want ErrNotFound", err)
+			}
+			if err := cat.brands.Delete(ctx, brand.ID, 0); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("delete after delete: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestProductListing(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			category, brand, _ := seed(t, cat, "Delta", "alpha", "Charlie", "Bravo")
+
+			minPrice := 20.0
+			products, total, err := cat.products.List(ctx, ProductQuery{
+				Page:   Page{Offset: 1, Limit: 2},
+				Filter: ProductFilter{CategoryID: &category.ID, MinPrice: &minPrice},
+				Sort:   []SortField{{Field: "price", Desc: true}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 3 {
+				t.Fatalf("total = %d, want 3", total)
+			}
+			if got := productNames(products); !slices.Equal(got, []string{"Charlie", "alpha"}) {
+				t.Fatalf("page = %v, want [Charlie alpha]", got)
+			}
+			if products[0].Brand.ID != brand.ID || products[0].Category.ID != category.ID {
+				t.Fatal("brand and category are not loaded")
+			}
+
+			products, _, err = cat.products.List(ctx, ProductQuery{Page: Page{Limit: 10}, Filter: ProductFilter{Name: "A"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := productNames(products); !slices.Equal(got, []string{"Delta", "alpha", "Charlie", "Bravo"}) {
+				t.Fatalf("name filter in ID order = %v", got)
+			}
+
+			products, _, err = cat.products.List(ctx, ProductQuery{Page: Page{Limit: 10}, Sort: []SortField{{Field: "name"}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := productNames(products); !slices.Equal(got, []string{"Bravo", "Charlie", "Delta", "alpha"}) {
+				t.Fatalf("sorted by name = %v", got)
+			}
+		})
+	}
+}
+
+func TestBrandPaging(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			for _, brandName := range []string{"Acme", "Bolt", "Crux"} {
+				brand := models.Brand{Name: brandName, CoverImage: "https://example.com/brand.png"}
+				if err := cat.brands.Create(ctx, &brand); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			brands, total, err := cat.brands.List(ctx, Page{Offset: 2, Limit: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 3 || len(brands) != 1 || brands[0].Name != "Crux" {
+				t.Fatalf("last page = %+v of %d, want [Crux] of 3", brands, total)
+			}
+		})
+	}
+}
+
+func TestProductKeyset(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			_, _, seeded := seed(t, cat, "A1", "A2", "A3", "A4", "A5")
+
+			query := ProductQuery{Page: Page{Limit: 2}, Sort: []SortField{{Field: "price"}}}
+			products, err := cat.products.ListKeyset(ctx, query, &Keyset{Boundary: seeded[1]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := productNames(products); !slices.Equal(got, []string{"A3", "A4"}) {
+				t.Fatalf("after A2 = %v, want [A3 A4]", got)
+			}
+
+			products, err = cat.products.ListKeyset(ctx, query, &Keyset{Boundary: seeded[3], Backward: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := productNames(products); !slices.Equal(got, []string{"A2", "A3"}) {
+				t.Fatalf("before A4 = %v, want [A2 A3]", got)
+			}
+		})
+	}
+}
+
+func TestProductUpdate(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			_, brand, seeded := seed(t, cat, "Phone")
+
+			// A stale embedded brand must not be written back
+			product := seeded[0]
+			product.Price = 99
+			product.Brand = models.Brand{ID: brand.ID, Name: "Stale", CoverImage: "https://example.com/stale.png"}
+			if err := cat.products.Update(ctx, &product); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := cat.products.FindByID(ctx, product.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Price != 99 || got.Brand.Name != "Acme" {
+				t.Fatalf("stored product has price %v, brand %q", got.Price, got.Brand.Name)
+			}
+
+			if err := cat.products.Delete(ctx, product.ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cat.products.FindByID(ctx, product.ID); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("FindByID after delete: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+
+			key := models.APIKey{Name: "Partner", Prefix: "sk_0a1b2c3d", Hash: "hash", Scopes: []string{"products:write"}}
+			if err := cat.apiKeys.Create(ctx, &key); err != nil {
+				t.Fatal(err)
+			}
+			got, err := cat.apiKeys.FindByPrefix(ctx, "sk_0a1b2c3d")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != key.ID || !slices.Equal(got.Scopes, []string{"products:write"}) {
+				t.Fatalf("found key = %+v", got)
+			}
+			if _, err := cat.apiKeys.FindByPrefix(ctx, "sk_ffffffff"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("unknown prefix: err = %v, want ErrNotFound", err)
+			}
+
+			used := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+			if err := cat.apiKeys.Touch(ctx, key.ID, used); err != nil {
+				t.Fatal(err)
+			}
+			first := used.Add(time.Second)
+			if err := cat.apiKeys.Revoke(ctx, key.ID, first); err != nil {
+				t.Fatal(err)
+			}
+			if err := cat.apiKeys.Revoke(ctx, key.ID, first.Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+			got, err = cat.apiKeys.FindByID(ctx, key.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.LastUsedAt == nil || !got.LastUsedAt.Equal(used) || got.RevokedAt == nil || !got.RevokedAt.Equal(first) {
+				t.Fatalf("stored key used %v, revoked %v; want %v and the first revocation %v", got.LastUsedAt, got.RevokedAt, used, first)
+			}
+			if got.Active(time.Now()) {
+				t.Fatal("revoked key is still active")
+			}
+			if err := cat.apiKeys.Revoke(ctx, 99, first); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("revoke unknown key: err = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func productNames(products []models.Product) []string {
+	names := make([]string, len(products))
+	for i, product := range products {
+		names[i] = product.Name
+	}
+	return names
+}
+
+func TestProductBatchRollback(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			_, _, seeded := seed(t, cat, "First", "Second")
+
+			first, second := seeded[0], seeded[1]
+			first.Price, second.Price = 1, 2
+			second.Version = 7
+			err := cat.products.UpdateMany(ctx, []models.Product{first, second})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("err = %v, want a version conflict on item 1", err)
+			}
+
+			err = cat.products.DeleteMany(ctx, []models.BulkDelete{{ID: seeded[0].ID}, {ID: 999}})
+			if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrNotFound) {
+				t.Fatalf("err = %v, want not found on item 1", err)
+			}
+
+			stored, err := cat.products.FindByIDs(ctx, []uint{seeded[1].ID, seeded[0].ID, seeded[0].ID, 999})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(stored) != 2 {
+				t.Fatalf("FindByIDs returned %d products, want 2", len(stored))
+			}
+			for i, product := range stored {
+				if product.ID != seeded[i].ID || product.Price != seeded[i].Price || product.Version != 1 {
+					t.Fatalf("product %d changed after a rolled back batch: %+v", product.ID, product)
+				}
+			}
+		})
+	}
+}
+
+func TestProductModified(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			modified := func() time.Time {
+				t.Helper()
+				at, err := cat.products.Modified(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return at
+			}
+
+			if at := modified(); !at.IsZero() {
+				t.Fatalf("empty table modified at %v, want the zero time", at)
+			}
+			_, _, products := seed(t, cat, "Alpha", "Beta", "Gamma")
+			created := modified()
+			if !created.Equal(products[2].UpdatedAt) {
+				t.Fatalf("modified = %v, want the newest updated_at %v", created, products[2].UpdatedAt)
+			}
+
+			// Deleting a row leaves the newest updated_at behind
+			if err := cat.products.Delete(ctx, products[0].ID, 0); err != nil {
+				t.Fatal(err)
+			}
+			deleted := modified()
+			if !deleted.After(created) {
+				t.Fatalf("modified after a delete = %v, want after %v", deleted, created)
+			}
+
+			// A failed delete changes nothing
+			if err := cat.products.Delete(ctx, products[1].ID, 7); !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("delete at a stale version: err = %v, want ErrVersionConflict", err)
+			}
+			err := cat.products.DeleteMany(ctx, []models.BulkDelete{{ID: products[1].ID}, {ID: products[0].ID}})
+			if !errors.Is(err, ErrNotFound) {
+				t.Fatalf("rolled back DeleteMany: err = %v, want ErrNotFound", err)
+			}
+			if at := modified(); !at.Equal(deleted) {
+				t.Fatalf("modified after failed deletes = %v, want %v", at, deleted)
+			}
+
+			if err := cat.products.DeleteMany(ctx, []models.BulkDelete{{ID: products[1].ID}, {ID: products[2].ID}}); err != nil {
+				t.Fatal(err)
+			}
+			if at := modified(); !at.After(deleted) {
+				t.Fatalf("modified after DeleteMany = %v, want after %v", at, deleted)
+			}
+		})
+	}
+}
+
+func TestProductSearch(t *testing.T) {
+	for name, open := range implementations() {
+		t.Run(name, func(t *testing.T) {
+			cat := open(t)
+			ctx := context.Background()
+			seed(t, cat, "Wireless headphones", "Wired earbuds", "Phone case")
+
+			hits, total, err := cat.products.Search(ctx, SearchQuery{Page: Page{Limit: 10}, Terms: SearchTerms("head")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total != 1 || len(hits) != 1 || hits[0].Product.Name != "Wireless headphones" {
+				t.Fatalf("search for head = %d hits, total %d", len(hits), total)
+			}
+			if hits[0].Highlights.Name != "Wireless <mark>head</mark>phones" {
+				t.Fatalf("name highlight = %q", hits[0].Highlights.Name)
+			}
+		})
+	}
+}
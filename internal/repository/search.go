@@ -0,0 +1,366 @@
+package repository
+
+import (
+	"Scalable-Secure-Go-Web/internal/models"
+	"context"
+	"gorm.io/gorm"
+	"html"
+	"log/slog"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MaxSearchTerms is the number of words a search may combine.
+const MaxSearchTerms = 10
+
+// excerptWords is the length of a description excerpt, in words.
+const excerptWords = 24
+
+// SearchQuery describes a page of search results. A product matches when its
+// name or description contains every term; filters narrow the matches as on a
+// listing. Results are ranked, so there is no sort.
+type SearchQuery struct {
+	Page
+	Terms  []string // as returned by SearchTerms
+	Filter ProductFilter
+}
+
+// SearchTerms splits text into the lower-cased words of a search, in order and
+// without repeats. Anything but letters and digits separates words, so no
+// operator of a database's query syntax ever reaches it.
+func SearchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if !slices.Contains(terms, w) {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// searchEngine is the way a database matches, ranks and highlights products.
+type searchEngine string
+
+const (
+	searchPostgres searchEngine = "postgres" // tsvector column with a GIN index
+	searchMySQL    searchEngine = "mysql"    // FULLTEXT index in boolean mode
+	searchFTS5     searchEngine = "fts5"     // SQLite FTS5 table kept by triggers
+	searchLike     searchEngine = "like"     // SQLite without FTS5: LIKE scans
+)
+
+// Highlights mark matches with these control characters, which are swapped
+// for <mark> tags once the text is HTML-escaped.
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// engine picks the search engine of the database the first time it is needed.
+// SQLite only has FTS5 when go-sqlite3 is built with -tags sqlite_fts5 (a plain
+// go build leaves it out), and the index only exists once the migrator has
+// created it.
+func (r *gormProductRepository) engine(ctx context.Context) searchEngine {
+	r.searchOnce.Do(func() {
+		switch r.db.Dialector.Name() {
+		case "postgres":
+			r.search = searchPostgres
+		case "mysql":
+			r.search = searchMySQL
+		default:
+			var fts5 bool
+			err := r.db.WithContext(ctx).Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5') AND EXISTS " +
+				"(SELECT 1 FROM sqlite_master WHERE type = 'trigger' AND name = 'products_fts_insert')").Scan(&fts5).Error
+			if err == nil && fts5 {
+				r.search = searchFTS5
+				return
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "Cannot tell whether SQLite has FTS5", "error", err)
+			}
+			slog.WarnContext(ctx, "Product search falls back to LIKE scans; build with -tags sqlite_fts5 and migrate to index it")
+			r.search = searchLike
+		}
+	})
+	return r.search
+}
+
+func (r *gormProductRepository) Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, int64, error) {
+	if len(query.Terms) == 0 {
+		return []models.SearchHit{}, 0, nil
+	}
+	engine := r.engine(ctx)
+	matched := func() *gorm.DB {
+		return r.db.WithContext(ctx).
+			Model(&models.Product{}).
+			Scopes(engine.match(query.Terms), filterProducts(query.Filter))
+	}
+
+	var total int64
+	if err := matched().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ranked []struct {
+		ID    uint
+		Score float64
+	}
+	score, args := engine.score(query.Terms)
+	if err := matched().
+		Select("products.id AS id, "+score+" AS score", args...).
+		Order("score DESC").
+		Order("products.id").
+		Limit(query.Limit).
+		Offset(query.Offset).
+		Scan(&ranked).Error; err != nil {
+		return nil, 0, err
+	}
+	if len(ranked) == 0 {
+		return []models.SearchHit{}, total, nil
+	}
+
+	ids := make([]uint, len(ranked))
+	for i, hit := range ranked {
+		ids[i] = hit.ID
+	}
+	products, err := r.FindByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	highlights, err := engine.highlight(r.db.WithContext(ctx), query.Terms, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	hits := make([]models.SearchHit, 0, len(ranked))
+	for _, hit := range ranked {
+		i := slices.IndexFunc(products, func(p models.Product) bool { return p.ID == hit.ID })
+		if i < 0 {
+			continue // deleted since it was ranked
+		}
+		h, ok := highlights[hit.ID]
+		if !ok {
+			h = highlightProduct(products[i], query.Terms)
+		}
+		hits = append(hits, models.SearchHit{Product: products[i], Score: hit.Score, Highlights: h})
+	}
+	return hits, total, nil
+}
+
+// match restricts a products query to the rows containing every term.
+func (e searchEngine) match(terms []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		switch e {
+		case searchPostgres:
+			return db.Where("search_vector @@ to_tsquery('english', ?)", tsquery(terms))
+		case searchMySQL:
+			return db.Where("MATCH(name, description) AGAINST (? IN BOOLEAN MODE)", booleanQuery(terms))
+		case searchFTS5:
+			// The relevance is joined from the index; hit_id and score cannot
+			// clash with the columns the filters name
+			return db.Joins("JOIN (SELECT rowid AS hit_id, -bm25(products_fts, 10.0, 1.0) AS score "+
+				"FROM products_fts WHERE products_fts MATCH ?) AS hits ON hits.hit_id = products.id", fts5Query(terms))
+		}
+		for _, term := range terms {
+			pattern := "%" + likeEscaper.Replace(term) + "%"
+			db = db.Where("(LOWER(name) LIKE ? ESCAPE '!' OR LOWER(description) LIKE ? ESCAPE '!')", pattern, pattern)
+		}
+		return db
+	}
+}
+
+// score returns the expression ranking a matched row; higher is more
+// relevant. Names weigh more than descriptions in every engine.
+func (e searchEngine) score(terms []string) (string, []any) {
+	switch e {
+	case searchPostgres:
+		return "ts_rank_cd(search_vector, to_tsquery('english', ?))", []any{tsquery(terms)}
+	case searchMySQL:
+		// MATCH over both columns ranks them alike, so the name index adds
+		// a second, doubled score for the terms found in the name
+		return "2 * MATCH(name) AGAINST (? IN BOOLEAN MODE) + MATCH(name, description) AGAINST (? IN BOOLEAN MODE)",
+			[]any{anyQuery(terms), booleanQuery(terms)}
+	case searchFTS5:
+		return "hits.score", nil
+	}
+	cases := make([]string, 0, 2*len(terms))
+	args := make([]any, 0, 2*len(terms))
+	for _, term := range terms {
+		pattern := "%" + likeEscaper.Replace(term) + "%"
+		cases = append(cases,
+			"CASE WHEN LOWER(name) LIKE ? ESCAPE '!' THEN 2 ELSE 0 END",
+			"CASE WHEN LOWER(description) LIKE ? ESCAPE '!' THEN 1 ELSE 0 END")
+		args = append(args, pattern, pattern)
+	}
+	return strings.Join(cases, " + "), args
+}
+
+// highlight returns the highlights the database computed for ids, by product
+// ID. Engines that cannot highlight return none and the caller falls back to
+// highlightProduct.
+func (e searchEngine) highlight(db *gorm.DB, terms []string, ids []uint) (map[uint]models.SearchHighlights, error) {
+	var rows []struct {
+		ID          uint
+		Name        string
+		Description string
+	}
+	switch e {
+	case searchPostgres:
+		q := tsquery(terms)
+		err := db.Raw("SELECT id, "+
+			"ts_headline('english', name, to_tsquery('english', ?), ?) AS name, "+
+			"ts_headline('english', description, to_tsquery('english', ?), ?) AS description "+
+			"FROM products WHERE id IN ?",
+			q, `HighlightAll=true, StartSel="`+markStart+`", StopSel="`+markEnd+`"`,
+			q, `MaxWords=24, MinWords=12, MaxFragments=2, FragmentDelimiter=" … ", StartSel="`+markStart+`", StopSel="`+markEnd+`"`,
+			ids).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	case searchFTS5:
+		err := db.Raw("SELECT rowid AS id, "+
+			"highlight(products_fts, 0, char(2), char(3)) AS name, "+
+			"snippet(products_fts, 1, char(2), char(3), '…', ?) AS description "+
+			"FROM products_fts WHERE products_fts MATCH ? AND rowid IN ?",
+			excerptWords, fts5Query(terms), ids).Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	highlights := make(map[uint]models.SearchHighlights, len(rows))
+	for _, row := range rows {
+		highlights[row.ID] = models.SearchHighlights{Name: markup(row.Name), Description: markup(row.Description)}
+	}
+	return highlights, nil
+}
+
+// tsquery requires every term as a word prefix: wire:* & head:*.
+func tsquery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// booleanQuery requires every term as a word prefix: +wire* +head*.
+func booleanQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "+" + term + "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+// anyQuery matches any term as a word prefix: wire* head*.
+func anyQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = term + "*"
+	}
+	return strings.Join(parts, " ")
+}
+
+// fts5Query requires every term as a token prefix: "wire"* "head"*.
+func fts5Query(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + term + `"*`
+	}
+	return strings.Join(parts, " ")
+}
+
+// matchScore ranks a product the way the LIKE engine does: two points for each
+// term in the name and one for each term in the description. It returns 0 when
+// a term is in neither.
+func matchScore(product models.Product, terms []string) float64 {
+	name, description := strings.ToLower(product.Name), strings.ToLower(product.Description)
+	var score float64
+	for _, term := range terms {
+		inName, inDescription := strings.Contains(name, term), strings.Contains(description, term)
+		if !inName && !inDescription {
+			return 0
+		}
+		if inName {
+			score += 2
+		}
+		if inDescription {
+			score++
+		}
+	}
+	return score
+}
+
+// highlightProduct marks the terms in the name and in an excerpt of the
+// description around the first match.
+func highlightProduct(product models.Product, terms []string) models.SearchHighlights {
+	return models.SearchHighlights{
+		Name:        markup(markTerms(product.Name, terms)),
+		Description: markup(excerpt(markTerms(product.Description, terms))),
+	}
+}
+
+// markTerms wraps every case-insensitive occurrence of the terms in text with
+// the match markers, merging overlapping matches.
+func markTerms(text string, terms []string) string {
+	runes := []rune(text)
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		t := []rune(term)
+		for i := 0; i+len(t) <= len(lower); i++ {
+			if slices.Equal(lower[i:i+len(t)], t) {
+				for j := i; j < i+len(t); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i, r := range runes {
+		if marked[i] && (i == 0 || !marked[i-1]) {
+			b.WriteString(markStart)
+		}
+		b.WriteRune(r)
+		if marked[i] && (i == len(runes)-1 || !marked[i+1]) {
+			b.WriteString(markEnd)
+		}
+	}
+	return b.String()
+}
+
+// excerpt cuts marked text down to excerptWords words, starting a few words
+// before the first match. Terms hold no spaces, so no match is ever cut.
+func excerpt(text string) string {
+	words := strings.Fields(text)
+	if len(words) <= excerptWords {
+		return strings.Join(words, " ")
+	}
+	start := slices.IndexFunc(words, func(w string) bool { return strings.Contains(w, markStart) })
+	start = min(max(start-excerptWords/4, 0), len(words)-excerptWords)
+	out := strings.Join(words[start:start+excerptWords], " ")
+	if start > 0 {
+		out = "… " + out
+	}
+	if start+excerptWords < len(words) {
+		out += " …"
+	}
+	return out
+}
+
+// markup escapes text for HTML and turns the match markers into <mark> tags.
+func markup(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, markStart, "<mark>")
+	return strings.ReplaceAll(text, markEnd, "</mark>")
+}
@@ -0,0 +1,158 @@
+// Package respond writes the API's error responses as RFC 7807 problem
+// details. Every problem carries a stable code and the request ID so a client
+// report can be matched to the server logs. Titles, details and field messages
+// are translated into the request's language (see i18n); the detail arguments
+// below are English translation keys with "{0}"-style placeholders.
+package respond
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/logging"
+	"Scalable-Secure-Go-Web/internal/models"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEProblemJSON is the media type of problem responses.
+const MIMEProblemJSON = "application/problem+json"
+
+// Stable problem codes. Clients switch on these, never on the detail text.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidID            = "invalid_id"
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidQuery         = "invalid_query"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePreconditionRequired = "precondition_required"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodePatchFailed          = "patch_failed"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeRateLimited          = "rate_limited"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "unavailable"
+)
+
+// statusCodes is the default code for each status, used by Error.
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:            CodeBadRequest,
+	fiber.StatusUnauthorized:          CodeUnauthorized,
+	fiber.StatusForbidden:             CodeForbidden,
+	fiber.StatusNotFound:              CodeNotFound,
+	fiber.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	fiber.StatusConflict:              CodeConflict,
+	fiber.StatusPreconditionFailed:    CodePreconditionFailed,
+	fiber.StatusPreconditionRequired:  CodePreconditionRequired,
+	fiber.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	fiber.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	fiber.StatusTooManyRequests:       CodeRateLimited,
+	fiber.StatusInternalServerError:   CodeInternal,
+	fiber.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// Problem writes a problem response with an explicit code.
+func Problem(c *fiber.Ctx, status int, code, detail string, params ...string) error {
+	trans := i18n.FromContext(c.UserContext())
+	return write(c, models.Problem{Status: status, Code: code, Detail: i18n.T(trans, detail, params...)})
+}
+
+// Error writes a problem response whose code is derived from status.
+func Error(c *fiber.Ctx, status int, detail string, params ...string) error {
+	return Problem(c, status, codeFor(status), detail, params...)
+}
+
+// ServerError logs err against the request and writes a 500 problem with
+// detail. err itself is never sent to the client.
+func ServerError(c *fiber.Ctx, detail string, err error) error {
+	slog.ErrorContext(c.UserContext(), detail, "error", err)
+	return Error(c, fiber.StatusInternalServerError, detail)
+}
+
+// Invalid writes a 400 validation_failed problem listing the invalid fields.
+// Each Message is a translation key whose "{0}" is filled with the field's
+// Param.
+func Invalid(c *fiber.Ctx, fields ...models.FieldError) error {
+	Localize(c, fields)
+	return invalid(c, fields)
+}
+
+// Localize translates each field's Message key in place, filling "{0}" with
+// the field's Param.
+func Localize(c *fiber.Ctx, fields []models.FieldError) {
+	trans := i18n.FromContext(c.UserContext())
+	for i := range fields {
+		fields[i].Message = i18n.T(trans, fields[i].Message, fields[i].Param)
+	}
+}
+
+// Rejected writes a problem with an explicit code that lists already
+// translated field errors.
+func Rejected(c *fiber.Ctx, status int, code string, fields []models.FieldError, detail string, params ...string) error {
+	trans := i18n.FromContext(c.UserContext())
+	return write(c, models.Problem{
+		Status: status,
+		Code:   code,
+		Detail: i18n.T(trans, detail, params...),
+		Errors: fields,
+	})
+}
+
+// invalid writes a validation_failed problem for already translated fields.
+func invalid(c *fiber.Ctx, fields []models.FieldError) error {
+	detail := i18n.T(i18n.FromContext(c.UserContext()), "The request has invalid fields")
+	if len(fields) == 1 {
+		detail = fields[0].Message
+	}
+	return write(c, models.Problem{
+		Status: fiber.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: detail,
+		Errors: fields,
+	})
+}
+
+// ErrorHandler renders errors that escape the handlers (unmatched routes,
+// oversized bodies, recovered panics) as problems. Unexpected errors are
+// logged and hidden behind a generic detail unless development is set.
+func ErrorHandler(development bool) fiber.ErrorHandler {
+	return func(c *fiber.Ctx, err error) error {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return Error(c, fiberErr.Code, fiberErr.Message)
+		}
+
+		detail := "Internal server error"
+		if development {
+			detail = err.Error()
+		}
+		return ServerError(c, detail, err)
+	}
+}
+
+// write fills in the members shared by every problem and sends it.
+func write(c *fiber.Ctx, problem models.Problem) error {
+	problem.Type = "about:blank"
+	problem.Title = i18n.T(i18n.FromContext(c.UserContext()), http.StatusText(problem.Status))
+	problem.Instance = c.Path()
+	problem.RequestID = logging.RequestID(c.UserContext())
+	return c.Status(problem.Status).JSON(problem, MIMEProblemJSON)
+}
+
+func codeFor(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= fiber.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -0,0 +1,95 @@
+package respond
+
+import (
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/models"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Validator returns the shared validator. It reports fields by their JSON
+// (or, for query structs, query) names, as clients know them, and has rule
+// messages for every supported language. Translations register on the shared
+// i18n translators, which is why there is only one instance.
+var Validator = sync.OnceValue(func() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "query"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	if err := i18n.RegisterValidator(validate); err != nil {
+		panic(err)
+	}
+	return validate
+})
+
+// ValidationFailed writes a validation_failed problem for an error returned
+// by Validator, with rule messages in the request's
+// language. Other errors become a plain bad_request.
+func ValidationFailed(c *fiber.Ctx, err error) error {
+	fields, ok := FieldErrors(c, err)
+	if !ok {
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	}
+	return invalid(c, fields)
+}
+
+// FieldErrors converts an error returned by Validator into field errors with
+// messages in the request's language. ok is false for any other error.
+func FieldErrors(c *fiber.Ctx, err error) (fields []models.FieldError, ok bool) {
+	return TranslateFieldErrors(i18n.FromContext(c.UserContext()), err)
+}
+
+// TranslateFieldErrors is FieldErrors for work done outside a request, such
+// as the import command.
+func TranslateFieldErrors(trans ut.Translator, err error) (fields []models.FieldError, ok bool) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	fields = make([]models.FieldError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields = append(fields, fieldError(trans, fe))
+	}
+	return fields, true
+}
+
+// fieldError converts a validator field error into the API's form.
+func fieldError(trans ut.Translator, fe validator.FieldError) models.FieldError {
+	field := fieldPath(fe)
+	message := fe.Translate(trans)
+	if message == fe.Error() {
+		// validator has no message for this rule
+		message = i18n.T(trans, "{0} failed the {1} rule", field, fe.Tag())
+	}
+	return models.FieldError{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Param:   fe.Param(),
+		Message: message,
+	}
+}
+
+// fieldPath drops the struct name from the namespace: "Product.name" → "name",
+// "APIKeyInput.scopes[0]" → "scopes[0]".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
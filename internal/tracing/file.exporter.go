@@ -0,0 +1,148 @@
+package tracing
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// newFileExporter returns the standard OTLP/HTTP exporter wired to a transport
+// that writes each export request to path instead of sending it, so spans are
+// encoded exactly as a collector would receive them.
+func newFileExporter(ctx context.Context, path string) (*fileExporter, error) {
+	if err := ensureDir(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpoint("otlp-file.invalid"),
+		otlptracehttp.WithInsecure(),
+		otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+		otlptracehttp.WithHTTPClient(&http.Client{Transport: &fileTransport{file: file}}),
+	)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &fileExporter{Exporter: exporter, file: file}, nil
+}
+
+// fileExporter closes the output file after the exporter shuts down.
+type fileExporter struct {
+	*otlptrace.Exporter
+	file *os.File
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.Exporter.Shutdown(ctx), e.file.Close())
+}
+
+// fileTransport is an http.RoundTripper that appends OTLP protobuf export
+// requests to a file as OTLP/JSON lines and answers with success.
+type fileTransport struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (t *fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	defer req.Body.Close()
+
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var export coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(raw, &export); err != nil {
+		return nil, err
+	}
+	line, err := otlpJSON(&export)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	_, err = t.file.Write(append(line, '\n'))
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	response, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Status:        "200 OK",
+		Header:        http.Header{"Content-Type": []string{"application/x-protobuf"}},
+		Body:          io.NopCloser(bytes.NewReader(response)),
+		ContentLength: int64(len(response)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}, nil
+}
+
+// otlpJSON encodes an export request as OTLP/JSON. That format differs from
+// plain protojson in one way: trace and span IDs are hex, not base64.
+func otlpJSON(export *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	raw, err := protojson.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	hexIDs(doc)
+	return json.Marshal(doc)
+}
+
+// hexIDs rewrites every traceId, spanId and parentSpanId in doc from base64 to hex.
+func hexIDs(doc any) {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if id, ok := value.(string); ok && (key == "traceId" || key == "spanId" || key == "parentSpanId") {
+				if decoded, err := base64.StdEncoding.DecodeString(id); err == nil {
+					v[key] = hex.EncodeToString(decoded)
+				}
+				continue
+			}
+			hexIDs(value)
+		}
+	case []any:
+		for _, value := range v {
+			hexIDs(value)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Package tracing configures OpenTelemetry tracing.
+//
+// Spans are exported by a pluggable exporter: "stdout" prints them as JSON,
+// "otlp-file" appends OTLP/JSON export requests (one per line, the format read
+// by the OpenTelemetry Collector's otlpjsonfile receiver) to a file so traces
+// can be collected offline. Incoming and outgoing context uses W3C
+// traceparent/tracestate headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName names the tracer used by this module's instrumentation.
+const InstrumentationName = "Scalable-Secure-Go-Web"
+
+// Options selects and configures the span exporter.
+type Options struct {
+	ServiceName string
+	Exporter    string  // none, stdout or otlp-file
+	File        string  // output path for otlp-file
+	SampleRatio float64 // fraction of new traces recorded; parents' decisions are honoured
+}
+
+// Tracer returns the module's tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Setup installs the W3C propagator and, unless the exporter is "none", a
+// global tracer provider. The returned function flushes buffered spans and
+// must be called on shutdown.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New()
+	case "otlp-file":
+		exporter, err = newFileExporter(ctx, opts.File)
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q (use none, stdout or otlp-file)", opts.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", opts.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// ensureDir creates the parent directory of path.
+func ensureDir(path string) error {
+	return os.MkdirAll(filepath.Dir(path), 0o755)
+}
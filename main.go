@@ -4,17 +4,31 @@ import (
 	_ "Scalable-Secure-Go-Web/docs"
 	"Scalable-Secure-Go-Web/internal/config"
 	"Scalable-Secure-Go-Web/internal/handlers"
-	"Scalable-Secure-Go-Web/internal/models"
+	"Scalable-Secure-Go-Web/internal/health"
+	"Scalable-Secure-Go-Web/internal/i18n"
+	"Scalable-Secure-Go-Web/internal/idempotency"
+	"Scalable-Secure-Go-Web/internal/logging"
+	"Scalable-Secure-Go-Web/internal/metrics"
+	"Scalable-Secure-Go-Web/internal/middleware"
+	"Scalable-Secure-Go-Web/internal/ratelimit"
+	"Scalable-Secure-Go-Web/internal/repository"
+	"Scalable-Secure-Go-Web/internal/respond"
+	"Scalable-Secure-Go-Web/internal/tracing"
+	"context"
 	"fmt"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/helmet"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	fiberSwagger "github.com/swaggo/fiber-swagger"
 	"io"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
+	"runtime/debug"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -28,56 +42,149 @@ var startTime = time.Now()
 // @description A simple GoFiber + GORM + Swagger API for managing products, categories, and brands.
 // @host localhost:3000
 // @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT bearer token, e.g. "Bearer eyJhbGciOi..."
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Partner API key issued through /admin/api-keys
 func main() {
+	// Log JSON at info until the configuration says otherwise
+	bootLogger, _ := logging.New(os.Stdout, "info", "json")
+	slog.SetDefault(bootLogger)
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("config error: %v", err)
+		logging.Fatal("Invalid configuration", "error", err)
+	}
+
+	// Structured logs to stdout, and to a rotating LOG_FILE when enabled
+	var output io.Writer = os.Stdout
+	var logFile *logging.RotatingFile
+	if cfg.LogToFile {
+		logFile, err = logging.OpenRotatingFile(logging.RotateOptions{
+			Path:     cfg.LogFile,
+			Mode:     cfg.LogFileMode,
+			MaxSize:  cfg.LogMaxSize,
+			Interval: cfg.LogRotateInterval,
+			MaxAge:   cfg.LogMaxAge,
+			MaxFiles: cfg.LogMaxFiles,
+			Compress: cfg.LogCompress,
+		})
+		if err != nil {
+			logging.Fatal("Failed to open log file", "error", err)
+		}
+		output = io.MultiWriter(os.Stdout, logFile)
+	}
+	logger, err := logging.New(output, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		logging.Fatal("Invalid logging configuration", "error", err)
+	}
+	slog.SetDefault(logger)
+	slog.Info("Loaded configuration", "config", cfg)
+
+	// Translation catalogs on top of the built-in French and Spanish ones
+	if cfg.I18nDir != "" {
+		if err := i18n.Load(cfg.I18nDir); err != nil {
+			logging.Fatal("Failed to load translations", "dir", cfg.I18nDir, "error", err)
+		}
+	}
+
+	// "migrate" subcommand: manage the schema and exit
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		cfg.MigrateOnStart = false
+		config.Connect(cfg)
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			logging.Fatal("Migration failed", "error", err)
+		}
+		return
+	}
+
+	// "import" subcommand: load a product feed and exit
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		config.Connect(cfg)
+		if err := runImport(os.Args[2:]); err != nil {
+			logging.Fatal("Import failed", "error", err)
+		}
+		return
+	}
+
+	// "export" subcommand: dump a table to a file and exit
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		config.Connect(cfg)
+		if err := runExport(os.Args[2:]); err != nil {
+			logging.Fatal("Export failed", "error", err)
+		}
+		return
+	}
+
+	// Tracing first, so startup queries are traced too
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName: cfg.ServiceName,
+		Exporter:    cfg.TracingExporter,
+		File:        cfg.TracingFile,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logging.Fatal("Failed to set up tracing", "error", err)
 	}
 
 	// Setup DB (SQLite for demo; swap for Postgres/MySQL in prod)
 	config.Connect(cfg)
 
-	// Initialize Fiber
-	app := fiber.New()
+	// Repositories and the handlers they back
+	productRepo := repository.NewGormProductRepository(config.DB)
+	categoryRepo := repository.NewGormCategoryRepository(config.DB)
+	brandRepo := repository.NewGormBrandRepository(config.DB)
+	apiKeyRepo := repository.NewGormAPIKeyRepository(config.DB)
 
-	//⃣ Global middleware
-	var output io.Writer = os.Stdout
+	productHandler := handlers.NewProductHandler(productRepo, categoryRepo, brandRepo, cfg.CursorSecret)
+	categoryHandler := handlers.NewCategoryHandler(categoryRepo)
+	brandHandler := handlers.NewBrandHandler(brandRepo)
 
-	// Optional file logging
-	if cfg.LogToFile {
-		file := handlers.SetupLogFile()
-		output = io.MultiWriter(os.Stdout, file)
+	// Rate limit counters: in process, or shared through the database
+	var limitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimitStore == "sql" {
+		limitStore = ratelimit.NewSQLStore(config.DB)
 	}
 
-	// Unified logger config
-	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] | ${status} | ${latency} | ${method} ${path} | ${ip}\n",
-		TimeFormat: time.RFC3339,
-		TimeZone:   "Local",
-		Output:     output,
-	}))
+	// Initialize Fiber; client IPs come from X-Forwarded-For only behind trusted proxies
+	app := fiber.New(fiber.Config{
+		ErrorHandler:            respond.ErrorHandler(cfg.Environment == "development"),
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+		// Bodies stream so product feeds need not fit in memory; BufferBody
+		// and StreamBody enforce the limits, BodyLimit is only read ahead
+		BodyLimit:         int(cfg.BodyLimit),
+		StreamRequestBody: true,
+	})
+
+	//⃣ Global middleware: request ID and language, then tracing, then the access log
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Locale())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.RequestLogger())
+
+	// Prometheus request metrics, and the /metrics endpoint itself
+	if cfg.MetricsEnabled {
+		app.Use(middleware.Metrics())
+		app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
+	}
 
 	// Register Swagger route
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
 
-	// Panic - proof the thing.
+	// Panic - proof the thing. The error handler renders the 500 envelope.
 	app.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
 		StackTraceHandler: func(c *fiber.Ctx, err interface{}) {
-			log.Printf("🔥 Panic: %v", err)
-
-			msg := "Internal server error"
-			if cfg.Environment == "development" {
-				msg = fmt.Sprintf("Panic: %v", err)
-			}
-
-			_ = c.Status(fiber.StatusInternalServerError).JSON(models.APIResponse{
-				Status:     "error",
-				StatusCode: fiber.StatusInternalServerError,
-				Data:       nil,
-				Message:    msg,
-			})
+			slog.ErrorContext(c.UserContext(), "Panic recovered", "panic", fmt.Sprint(err), "stack", string(debug.Stack()))
+			metrics.Panics.Inc()
 		},
 	}))
 
@@ -88,7 +195,8 @@ func main() {
 			app.Use(cors.New(cors.Config{
 				AllowOrigins:     join(cfg.FrontendOrigins, ","),
 				AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-				AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+				AllowHeaders:     "Origin, Content-Type, Accept, Accept-Language, Authorization, X-API-Key, X-Request-ID, If-Match, If-None-Match, If-Modified-Since, Idempotency-Key",
+				ExposeHeaders:    "ETag, Idempotent-Replayed",
 				AllowCredentials: cfg.CORSAllowCreds,
 			}))
 		}
@@ -106,61 +214,219 @@ func main() {
 			}))
 		}
 
-		// 4 Rate limiter – optional toggle
-		if cfg.EnableLimiter {
-			app.Use(limiter.New(limiter.Config{
-				Max:          cfg.RateLimitMax,
-				Expiration:   cfg.RateLimitWindow,
-				LimitReached: tooManyRequestsJSON,
-			}))
-		}
-
 	} else {
 		app.Use(cors.New(cors.Config{
-			AllowOrigins: "*", // or restrict with a comma-separated list
-			AllowHeaders: "Origin, Content-Type, Accept",
+			AllowOrigins:  "*", // or restrict with a comma-separated list
+			AllowHeaders:  "Origin, Content-Type, Accept, Accept-Language, Authorization, X-API-Key, X-Request-ID, If-Match, If-None-Match, If-Modified-Since, Idempotency-Key",
+			ExposeHeaders: "ETag, Idempotent-Replayed",
 		}))
 	}
 
-	// Fake route to prove it works
-	app.Get("/health", healthJSON)
+	// Probes: liveness is process-only, readiness checks dependencies and
+	// fails once shutdown begins
+	migrator, err := config.NewMigrator(cfg)
+	if err != nil {
+		logging.Fatal("Failed to load migrations", "error", err)
+	}
+	state := health.NewState()
+	state.AddCheck(health.Check{Name: "database", Critical: true, Run: config.Ping})
+	state.AddCheck(health.Check{Name: "migrations", Critical: true, Run: func(ctx context.Context) error {
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migration(s), latest is %d", len(pending), migrator.Latest())
+		}
+		return nil
+	}})
+	app.Get("/livez", livezJSON)
+	app.Get("/readyz", readyzJSON(state, cfg.HealthCheckTimeout))
+	app.Get("/health", healthJSON(state))
+
+	// Bearer or API key auth and role permissions for every mutating route;
+	// what it guards is answered "Cache-Control: private, no-store"
+	if !cfg.AuthEnabled {
+		slog.Warn("AUTH_ENABLED=false: write endpoints are open to anyone")
+	}
+	auth := middleware.Authenticate(cfg, apiKeyRepo)
+	rbac := middleware.NewRBAC(cfg)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyRepo, rbac)
+
+	// Rate limits per API key, user or IP; write, admin and export routes add stricter tiers
+	limits := middleware.NewRateLimiter(cfg, limitStore)
+	write := limits.Tier("write")
+	admin := limits.Tier("admin")
+	export := limits.Tier("export")
+
+	// Optimistic concurrency: optionally make If-Match mandatory on writes
+	ifMatch := middleware.RequireIfMatch(cfg)
+
+	// Retried POSTs with the same Idempotency-Key replay the first response
+	idempotent := middleware.Idempotency(cfg, idempotency.NewStore(config.DB))
+
+	// Imports that create missing categories and brands need write access to them too
+	createsMissing := func(c *fiber.Ctx) bool { return c.QueryBool("create_missing") }
+
+	// Request bodies are read into memory up to BODY_LIMIT, except product
+	// feeds, which the import route streams up to IMPORT_MAX_SIZE
+	importing := func(c *fiber.Ctx) bool {
+		return c.Method() == fiber.MethodPost && strings.TrimSuffix(c.Path(), "/") == "/api/v1/products/import"
+	}
+	app.Use(middleware.BufferBody(cfg.BodyLimit, importing))
 
 	// API version group
-	api := app.Group("/api/v1")
+	api := app.Group("/api/v1", middleware.Identify(cfg, apiKeyRepo), limits.Tier("default"))
 
 	// Product routes group
-	productApi := api.Group("/products")
-	productApi.Get("/", handlers.GetAllProducts)
-	productApi.Get("/:id", handlers.GetProductByID)
-	productApi.Post("/", handlers.CreateProduct)
-	productApi.Put("/:id", handlers.UpdateProduct)
-	productApi.Delete("/:id", handlers.DeleteProduct)
+	productApi := api.Group("/products", middleware.CacheControl(cfg.CacheControl["products"]))
+	productApi.Get("/", productHandler.GetAllProducts)
+	productApi.Get("/search", productHandler.SearchProducts)
+	productApi.Get("/export", auth, export, rbac.Require("products:read"), productHandler.ExportProducts)
+	productApi.Get("/:id", productHandler.GetProductByID)
+	productApi.Post("/", auth, write, rbac.Require("products:write"), idempotent, productHandler.CreateProduct)
+	productApi.Post("/bulk", auth, write, rbac.Require("products:write"), idempotent, productHandler.BulkCreateProducts)
+	productApi.Post("/import", auth, write, rbac.Require("products:write"),
+		rbac.RequireWhen(createsMissing, "categories:write"), rbac.RequireWhen(createsMissing, "brands:write"),
+		middleware.StreamBody(cfg.ImportMaxSize), idempotent, productHandler.ImportProducts)
+	productApi.Put("/bulk", auth, write, rbac.Require("products:write"), productHandler.BulkUpdateProducts)
+	productApi.Delete("/bulk", auth, write, rbac.Require("products:delete"), productHandler.BulkDeleteProducts)
+	productApi.Put("/:id", auth, write, rbac.Require("products:write"), ifMatch, productHandler.UpdateProduct)
+	productApi.Patch("/:id", auth, write, rbac.Require("products:write"), ifMatch, productHandler.PatchProduct)
+	productApi.Delete("/:id", auth, write, rbac.Require("products:delete"), ifMatch, productHandler.DeleteProduct)
 
 	// Category routes group
-	categoryApi := api.Group("/categories")
-	categoryApi.Get("/", handlers.GetAllCategories)
-	categoryApi.Get("/:id", handlers.GetCategoryByID)
-	categoryApi.Post("/", handlers.CreateCategory)
-	categoryApi.Put("/:id", handlers.UpdateCategory)
-	categoryApi.Delete("/:id", handlers.DeleteCategory)
+	categoryApi := api.Group("/categories", middleware.CacheControl(cfg.CacheControl["categories"]))
+	categoryApi.Get("/", categoryHandler.GetAllCategories)
+	categoryApi.Get("/export", auth, export, rbac.Require("categories:read"), categoryHandler.ExportCategories)
+	categoryApi.Get("/:id", categoryHandler.GetCategoryByID)
+	categoryApi.Post("/", auth, write, rbac.Require("categories:write"), idempotent, categoryHandler.CreateCategory)
+	categoryApi.Post("/bulk", auth, write, rbac.Require("categories:write"), idempotent, categoryHandler.BulkCreateCategories)
+	categoryApi.Put("/bulk", auth, write, rbac.Require("categories:write"), categoryHandler.BulkUpdateCategories)
+	categoryApi.Delete("/bulk", auth, write, rbac.Require("categories:delete"), categoryHandler.BulkDeleteCategories)
+	categoryApi.Put("/:id", auth, write, rbac.Require("categories:write"), ifMatch, categoryHandler.UpdateCategory)
+	categoryApi.Patch("/:id", auth, write, rbac.Require("categories:write"), ifMatch, categoryHandler.PatchCategory)
+	categoryApi.Delete("/:id", auth, write, rbac.Require("categories:delete"), ifMatch, categoryHandler.DeleteCategory)
 
 	// Brand routes group
-	brandApi := api.Group("/brands")
-	brandApi.Get("/", handlers.GetAllBrands)
-	brandApi.Get("/:id", handlers.GetBrandByID)
-	brandApi.Post("/", handlers.CreateBrand)
-	brandApi.Put("/:id", handlers.UpdateBrand)
-	brandApi.Delete("/:id", handlers.DeleteBrand)
+	brandApi := api.Group("/brands", middleware.CacheControl(cfg.CacheControl["brands"]))
+	brandApi.Get("/", brandHandler.GetAllBrands)
+	brandApi.Get("/export", auth, export, rbac.Require("brands:read"), brandHandler.ExportBrands)
+	brandApi.Get("/:id", brandHandler.GetBrandByID)
+	brandApi.Post("/", auth, write, rbac.Require("brands:write"), idempotent, brandHandler.CreateBrand)
+	brandApi.Post("/bulk", auth, write, rbac.Require("brands:write"), idempotent, brandHandler.BulkCreateBrands)
+	brandApi.Put("/bulk", auth, write, rbac.Require("brands:write"), brandHandler.BulkUpdateBrands)
+	brandApi.Delete("/bulk", auth, write, rbac.Require("brands:delete"), brandHandler.BulkDeleteBrands)
+	brandApi.Put("/:id", auth, write, rbac.Require("brands:write"), ifMatch, brandHandler.UpdateBrand)
+	brandApi.Patch("/:id", auth, write, rbac.Require("brands:write"), ifMatch, brandHandler.PatchBrand)
+	brandApi.Delete("/:id", auth, write, rbac.Require("brands:delete"), ifMatch, brandHandler.DeleteBrand)
+
+	// Admin routes group
+	apiKeyApi := api.Group("/admin/api-keys", auth, admin)
+	apiKeyApi.Get("/", rbac.Require("apikeys:read"), apiKeyHandler.GetAllAPIKeys)
+	apiKeyApi.Post("/", rbac.Require("apikeys:write"), apiKeyHandler.CreateAPIKey)
+	apiKeyApi.Delete("/:id", rbac.Require("apikeys:delete"), apiKeyHandler.RevokeAPIKey)
 
 	//⃣ Start server
 	addr := fmt.Sprintf(":%d", cfg.Port)
-	log.Printf("⇨ Listening on %s", addr)
-	if err := app.Listen(addr); err != nil {
-		log.Fatalf("server error: %v", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Listening", "addr", addr)
+		serverErr <- app.Listen(addr)
+	}()
+
+	// SIGHUP reopens the log file after an external logrotate moved it
+	if logFile != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				if err := logFile.Reopen(); err != nil {
+					slog.Error("Failed to reopen log file", "error", err)
+					continue
+				}
+				slog.Info("Reopened log file", "path", cfg.LogFile)
+			}
+		}()
+	}
+
+	// Wait for a stop signal, or for the listener to fail
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		logging.Fatal("Server failed", "error", err)
+	case sig := <-quit:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+	signal.Stop(quit)
+
+	// Fail readiness first so load balancers stop sending traffic
+	state.StartDraining()
+	if cfg.ShutdownDelay > 0 {
+		slog.Info("Waiting for load balancers to notice", "delay", cfg.ShutdownDelay.String())
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	// Stop accepting connections and let in-flight requests finish
+	if err := app.ShutdownWithTimeout(cfg.ShutdownTimeout); err != nil {
+		slog.Warn("Requests still running were cut off", "timeout", cfg.ShutdownTimeout.String(), "error", err)
+	}
+
+	if err := config.Close(); err != nil {
+		slog.Warn("Failed to close database", "error", err)
+	}
+
+	// Flush buffered spans
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Shutdown complete")
+	if logFile != nil {
+		if err := logFile.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to close log file: %v\n", err)
+		}
+	}
+}
+
+// livezJSON reports that the process is up. It checks no dependencies, so a
+// database outage never gets the instance restarted.
+func livezJSON(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": "ok",
+		"uptime": time.Since(startTime).String(),
+	})
+}
+
+// readyzJSON runs the readiness checks and returns 503 if any critical check
+// fails or the instance is draining.
+func readyzJSON(state *health.State, timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		report := state.Probe(c.UserContext(), timeout)
+		if !report.Ready() {
+			c.Status(fiber.StatusServiceUnavailable)
+		}
+		return c.JSON(report)
+	}
+}
+
+// healthJSON reports process stats, or 503 once the instance is draining.
+func healthJSON(state *health.State) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if state.Draining() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"status": "draining",
+				"uptime": time.Since(startTime).String(),
+			})
+		}
+		return statsJSON(c)
 	}
 }
 
-func healthJSON(c *fiber.Ctx) error {
+func statsJSON(c *fiber.Ctx) error {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
@@ -181,16 +447,6 @@ func healthJSON(c *fiber.Ctx) error {
 	})
 }
 
-// tooManyRequestsJSON returns 429 with a JSON message.
-func tooManyRequestsJSON(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusTooManyRequests).JSON(models.APIResponse{
-		Status:     "error",
-		StatusCode: fiber.StatusTooManyRequests,
-		Data:       nil,
-		Message:    "Too many requests. Calm down, champ.",
-	})
-}
-
 // join is a tiny helper (because strings.Join needs []string anyway)
 func join(ss []string, sep string) string {
 	if len(ss) == 0 {
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"Scalable-Secure-Go-Web/internal/config"
+	"Scalable-Secure-Go-Web/internal/migrations"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+  up             apply all pending migrations
+  down [n]       roll back the last n migrations (default 1)
+  to <version>   migrate up or down to exactly <version> (0 rolls back everything)
+  status         list migrations and when they were applied`
+
+// runMigrate implements the "migrate" subcommand against the configured database.
+func runMigrate(cfg *config.App, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := config.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	var applied int
+	switch args[0] {
+	case "up":
+		applied, err = migrator.Up(ctx)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count %q", args[1])
+			}
+		}
+		applied, err = migrator.Down(ctx, steps)
+
+	case "to":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, perr := strconv.ParseInt(args[1], 10, 64)
+		if perr != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		applied, err = migrator.To(ctx, version)
+
+	case "status":
+		return printMigrationStatus(ctx, migrator)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if errors.Is(err, migrations.ErrNoChange) {
+		fmt.Println("Schema is up to date, nothing to do")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Applied %d migration(s)\n", applied)
+	return printMigrationStatus(ctx, migrator)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+	return w.Flush()
+}